@@ -0,0 +1,220 @@
+package mustache
+
+import "fmt"
+
+// Node is a node of the abstract syntax tree (AST) of a template, as returned
+// by AST. It is meant to be encoded as JSON for tools written in other
+// languages, so the JSON name of each field is given in its documentation.
+type Node struct {
+	// Type ("type") is one of "text", "section", "invertedSection",
+	// "variable", "unescapedVariable" or "partial".
+	Type string `json:"type"`
+	// Value ("value") is the literal text of a text node.
+	Value string `json:"value,omitempty"`
+	// Name ("name") is the name of a tag, without any `../` prefix or filters.
+	Name string `json:"name,omitempty"`
+	// Parents ("parents") is the number of `../` segments which prefixed the
+	// name.
+	Parents int `json:"parents,omitempty"`
+	// Filters ("filters") is the filter chain of a variable, as a list of
+	// {"name", "args"} objects.
+	Filters []FilterCall `json:"filters,omitempty"`
+	// Children ("children") are the nodes inside a section.
+	Children []*Node `json:"children,omitempty"`
+	// HasElse ("hasElse") reports whether a section has an else divider, in
+	// which case Else ("else") holds the nodes after the divider.
+	HasElse bool    `json:"hasElse,omitempty"`
+	Else    []*Node `json:"else,omitempty"`
+	// Start ("start") and End ("end") delimit the node in the template source.
+	// For sections, they span from the opening tag to the closing tag.
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+	// Tag ("tag") describes the tag of a node, or the opening tag of a
+	// section. It is omitted for text nodes.
+	Tag *Tag `json:"tag,omitempty"`
+	// Close ("close") describes the closing tag of a section.
+	Close *Tag `json:"close,omitempty"`
+}
+
+// Tag describes how a tag appeared in the template source.
+type Tag struct {
+	// Start ("start") and End ("end") delimit the tag, including its
+	// delimiters.
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+	// Raw ("raw") is the source text of the tag.
+	Raw string `json:"raw"`
+	// Delimiters ("delimiters") are the opening and closing delimiters in
+	// effect for the tag.
+	Delimiters [2]string `json:"delimiters"`
+	// Standalone ("standalone") reports whether the tag was the only content
+	// on its line, in which case the line is removed from the output.
+	Standalone bool `json:"standalone,omitempty"`
+}
+
+var nodeTypes = map[TokenType]string{
+	Text:              "text",
+	Section:           "section",
+	InvertedSection:   "invertedSection",
+	Variable:          "variable",
+	UnescapedVariable: "unescapedVariable",
+	Partial:           "partial",
+}
+
+// AST converts the tokens of a template into an abstract syntax tree.
+func AST(t *Template) []*Node {
+	return astNodes(encodeTokens(t.tokens))
+}
+
+// FromAST rebuilds a template from an abstract syntax tree returned by AST.
+// The options which the template was compiled with should be given again; in
+// particular WithFilters supplies any custom filter functions.
+func FromAST(nodes []*Node, opts ...CompileOption) (*Template, error) {
+	p := &parser{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	enc, filters, err := encodedTokens(nodes)
+	if err != nil {
+		return nil, err
+	}
+	if filters && p.funcs == nil {
+		WithFilters(nil)(p)
+	}
+	tokens, err := p.decodeTokens(enc)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{tokens: tokens, funcs: p.funcs}, nil
+}
+
+func astNodes(enc []encodedToken) []*Node {
+	if len(enc) == 0 {
+		return nil
+	}
+	nodes := make([]*Node, len(enc))
+	for i, e := range enc {
+		n := &Node{
+			Type:     nodeTypes[e.Type],
+			Value:    e.Value,
+			Name:     e.Name,
+			Parents:  e.Parents,
+			Filters:  e.Filters,
+			Children: astNodes(e.Tokens),
+			HasElse:  e.HasElse,
+			Else:     astNodes(e.ElseTokens),
+			Start:    e.Source.Start,
+			End:      e.Source.End,
+		}
+		if e.Type != Text {
+			n.Tag = astTag(e.Source)
+		}
+		if e.Close != nil {
+			n.Close = astTag(*e.Close)
+			n.End = e.Close.End
+		}
+		nodes[i] = n
+	}
+	return nodes
+}
+
+func astTag(s encodedSource) *Tag {
+	return &Tag{
+		Start:      s.Start,
+		End:        s.End,
+		Raw:        s.Raw,
+		Delimiters: [2]string{"{{", "}}"},
+		Standalone: s.Standalone,
+	}
+}
+
+// encodedTokens converts AST nodes back into their encoded form, reporting
+// whether any filters are used.
+func encodedTokens(nodes []*Node) ([]encodedToken, bool, error) {
+	enc := make([]encodedToken, len(nodes))
+	filters := false
+	for i, n := range nodes {
+		if n == nil {
+			return nil, false, fmt.Errorf("Missing node")
+		}
+		tokenType, ok := Text, false
+		for t, name := range nodeTypes {
+			if name == n.Type {
+				tokenType, ok = t, true
+				break
+			}
+		}
+		if !ok {
+			return nil, false, fmt.Errorf("Invalid node type %q", n.Type)
+		}
+		if err := checkNode(tokenType, n); err != nil {
+			return nil, false, err
+		}
+
+		children, childFilters, err := encodedTokens(n.Children)
+		if err != nil {
+			return nil, false, err
+		}
+		elseTokens, elseFilters, err := encodedTokens(n.Else)
+		if err != nil {
+			return nil, false, err
+		}
+		filters = filters || childFilters || elseFilters || len(n.Filters) > 0
+
+		enc[i] = encodedToken{
+			Type:       tokenType,
+			Source:     encodedSource{Start: n.Start, End: n.End},
+			Value:      n.Value,
+			Name:       n.Name,
+			Parents:    n.Parents,
+			Filters:    n.Filters,
+			Tokens:     children,
+			HasElse:    n.HasElse,
+			ElseTokens: elseTokens,
+		}
+		if n.Tag != nil {
+			enc[i].Source = encodedSource{Start: n.Tag.Start, End: n.Tag.End, Raw: n.Tag.Raw, Standalone: n.Tag.Standalone}
+		}
+		if n.Close != nil {
+			enc[i].Close = &encodedSource{Start: n.Close.Start, End: n.Close.End, Raw: n.Close.Raw, Standalone: n.Close.Standalone}
+		}
+	}
+	return enc, filters, nil
+}
+
+// checkNode reports an error if a node has fields which don't apply to its
+// type or breaks a naming rule enforced by the parser, since the AST may come
+// from an external tool.
+func checkNode(tokenType TokenType, n *Node) error {
+	isSection := tokenType == Section || tokenType == InvertedSection
+	isVariable := tokenType == Variable || tokenType == UnescapedVariable
+	switch {
+	case tokenType == Text && (n.Name != "" || n.Parents != 0 || n.Tag != nil):
+		return fmt.Errorf("Text node with tag fields")
+	case tokenType != Text && n.Value != "":
+		return fmt.Errorf("Node of type %q with a value", n.Type)
+	case tokenType == Partial && n.Name == "":
+		return fmt.Errorf("Node of type %q without a name", n.Type)
+	case n.Parents > 0 && n.Name == "":
+		// Like the parser, allow empty names except after `../`.
+		return fmt.Errorf("Node of type %q with parents but without a name", n.Type)
+	case n.Parents < 0 || (tokenType == Partial && n.Parents != 0):
+		return fmt.Errorf("Invalid parents %d in node of type %q", n.Parents, n.Type)
+	case !isVariable && len(n.Filters) > 0:
+		return fmt.Errorf("Filters in node of type %q", n.Type)
+	case !isSection && len(n.Children) > 0:
+		return fmt.Errorf("Children in node of type %q", n.Type)
+	case !isSection && (n.HasElse || len(n.Else) > 0):
+		return fmt.Errorf("Else branch in node of type %q", n.Type)
+	case !n.HasElse && len(n.Else) > 0:
+		return fmt.Errorf("Else branch in section %q without hasElse", n.Name)
+	case !isSection && n.Close != nil:
+		return fmt.Errorf("Closing tag in node of type %q", n.Type)
+	}
+	for _, f := range n.Filters {
+		if f.Name == "" {
+			return fmt.Errorf("Missing filter name in node %q", n.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package mustache
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAST(t *testing.T) {
+	tmpl, err := Compile("Hi {{name | upper}}\n{{#items}}\n{{>item}}{{/items}}", WithFilters(nil))
+	if !assert.NoError(t, err) {
+		return
+	}
+	nodes := AST(tmpl)
+	if !assert.Len(t, nodes, 4) {
+		return
+	}
+	assert.Equal(t, &Node{
+		Type:  "text",
+		Value: "Hi ",
+		Start: Position{0, 1, 1},
+		End:   Position{3, 1, 4},
+	}, nodes[0])
+	assert.Equal(t, &Node{
+		Type:    "variable",
+		Name:    "name",
+		Filters: []FilterCall{{Name: "upper"}},
+		Start:   Position{3, 1, 4},
+		End:     Position{19, 1, 20},
+		Tag: &Tag{
+			Start:      Position{3, 1, 4},
+			End:        Position{19, 1, 20},
+			Raw:        "{{name | upper}}",
+			Delimiters: [2]string{"{{", "}}"},
+		},
+	}, nodes[1])
+
+	s := nodes[3]
+	assert.Equal(t, "section", s.Type)
+	assert.Equal(t, Position{20, 2, 1}, s.Start)
+	assert.Equal(t, Position{50, 3, 20}, s.End)
+	assert.True(t, s.Tag.Standalone)
+	assert.Equal(t, "{{/items}}", s.Close.Raw)
+	if assert.Len(t, s.Children, 1) {
+		assert.Equal(t, "partial", s.Children[0].Type)
+		assert.Equal(t, "item", s.Children[0].Name)
+	}
+}
+
+func TestASTJSON(t *testing.T) {
+	tmpl, err := Compile("{{^a}}{{{b}}}{{/a}}")
+	if !assert.NoError(t, err) {
+		return
+	}
+	data, err := json.Marshal(AST(tmpl))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.JSONEq(t, `[{
+		"type": "invertedSection",
+		"name": "a",
+		"children": [{
+			"type": "unescapedVariable",
+			"name": "b",
+			"start": {"offset": 6, "line": 1, "column": 7},
+			"end": {"offset": 13, "line": 1, "column": 14},
+			"tag": {
+				"start": {"offset": 6, "line": 1, "column": 7},
+				"end": {"offset": 13, "line": 1, "column": 14},
+				"raw": "{{{b}}}",
+				"delimiters": ["{{", "}}"]
+			}
+		}],
+		"start": {"offset": 0, "line": 1, "column": 1},
+		"end": {"offset": 19, "line": 1, "column": 20},
+		"tag": {
+			"start": {"offset": 0, "line": 1, "column": 1},
+			"end": {"offset": 6, "line": 1, "column": 7},
+			"raw": "{{^a}}",
+			"delimiters": ["{{", "}}"]
+		},
+		"close": {
+			"start": {"offset": 13, "line": 1, "column": 14},
+			"end": {"offset": 19, "line": 1, "column": 20},
+			"raw": "{{/a}}",
+			"delimiters": ["{{", "}}"]
+		}
+	}]`, string(data))
+}
+
+func TestFromAST(t *testing.T) {
+	tmpl, err := Compile(encodingTemplate, WithFilters(nil), WithElseDivider())
+	if !assert.NoError(t, err) {
+		return
+	}
+	data, err := json.Marshal(AST(tmpl))
+	if !assert.NoError(t, err) {
+		return
+	}
+	var nodes []*Node
+	if !assert.NoError(t, json.Unmarshal(data, &nodes)) {
+		return
+	}
+	restored, err := FromAST(nodes)
+	if assert.NoError(t, err) {
+		assert.Equal(t, tmpl.Tokens(), restored.Tokens())
+		assert.NotNil(t, restored.funcs)
+	}
+
+	// The parser accepts tags with empty names, so the AST must too.
+	for _, template := range []string{"{{}}", "{{{}}}", "{{#}}{{/}}", "{{#}}x{{/}}", "{{^}}x{{/}}"} {
+		tmpl, err := Compile(template)
+		if !assert.NoError(t, err, template) {
+			continue
+		}
+		restored, err := FromAST(AST(tmpl))
+		if assert.NoError(t, err, template) {
+			assert.Equal(t, tmpl.Tokens(), restored.Tokens(), template)
+		}
+	}
+
+	_, err = FromAST([]*Node{{Type: "section", Name: "a", Children: []*Node{{Type: "variable", Name: "b", Filters: []FilterCall{{Name: "shout"}}}}}})
+	assert.Error(t, err)
+	_, err = FromAST([]*Node{{Type: "variable", Name: "b", Filters: []FilterCall{{Name: "shout"}}}}, WithFilters(FuncMap{"shout": upperFilter}))
+	assert.NoError(t, err)
+}
+
+func TestFromASTMalformed(t *testing.T) {
+	tests := []struct {
+		json string
+		err  string
+	}{
+		{`[{"type":"comment"}]`, `Invalid node type "comment"`},
+		{`[null]`, `Missing node`},
+		{`[{"type":"section","name":"a","children":[null]}]`, `Missing node`},
+		{`[{"type":"section","name":"a","hasElse":true,"else":[null]}]`, `Missing node`},
+		{`[{"type":"text","value":"x","children":[{"type":"text"}]}]`, `Children in node of type "text"`},
+		{`[{"type":"text","name":"x"}]`, `Text node with tag fields`},
+		{`[{"type":"variable","parents":1}]`, `Node of type "variable" with parents but without a name`},
+		{`[{"type":"section","parents":2,"children":[]}]`, `Node of type "section" with parents but without a name`},
+		{`[{"type":"partial"}]`, `Node of type "partial" without a name`},
+		{`[{"type":"variable","name":"a","value":"x"}]`, `Node of type "variable" with a value`},
+		{`[{"type":"variable","name":"a","parents":-1}]`, `Invalid parents -1 in node of type "variable"`},
+		{`[{"type":"partial","name":"a","parents":1}]`, `Invalid parents 1 in node of type "partial"`},
+		{`[{"type":"section","name":"a","filters":[{"name":"upper"}]}]`, `Filters in node of type "section"`},
+		{`[{"type":"text","filters":[{"name":"upper"}]}]`, `Filters in node of type "text"`},
+		{`[{"type":"variable","name":"a","filters":[{"name":""}]}]`, `Missing filter name in node "a"`},
+		{`[{"type":"partial","name":"a","children":[{"type":"text"}]}]`, `Children in node of type "partial"`},
+		{`[{"type":"variable","name":"a","hasElse":true}]`, `Else branch in node of type "variable"`},
+		{`[{"type":"text","else":[{"type":"text"}]}]`, `Else branch in node of type "text"`},
+		{`[{"type":"section","name":"a","else":[{"type":"text"}]}]`, `Else branch in section "a" without hasElse`},
+		{`[{"type":"variable","name":"a","close":{"raw":"{{/a}}"}}]`, `Closing tag in node of type "variable"`},
+		{`[{"type":"variable","name":"a","filters":[{"name":"truncate"}]}]`, `Filter "truncate" expects 1 argument(s), got 0`},
+	}
+	for _, test := range tests {
+		var nodes []*Node
+		if !assert.NoError(t, json.Unmarshal([]byte(test.json), &nodes), test.json) {
+			continue
+		}
+		_, err := FromAST(nodes)
+		assert.EqualError(t, err, test.err, test.json)
+	}
+}
@@ -0,0 +1,145 @@
+package mustache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+)
+
+// Cache holds compiled templates keyed by name and a hash of their contents.
+// It bounds the number of templates held using least recently used eviction,
+// and concurrent calls to Compile for the same key share a single compilation.
+// Its methods are safe for concurrent use.
+type Cache struct {
+	size int
+	opts []CompileOption
+
+	mu       sync.Mutex
+	lru      *list.List // of *cacheEntry, most recently used at the front
+	entries  map[cacheKey]*list.Element
+	names    map[string]cacheKey
+	inflight map[cacheKey]*cacheCall
+	stats    CacheStats
+}
+
+// CacheStats reports the counters of a Cache.
+type CacheStats struct {
+	// Hits counts calls to Compile answered without compiling, including
+	// calls which waited on a concurrent compilation of the same key.
+	Hits uint64
+	// Misses counts calls to Compile which compiled the template.
+	Misses uint64
+	// Evictions counts templates removed to stay within the size of the
+	// cache, or because the contents of a name changed.
+	Evictions uint64
+	// Len is the number of templates currently held.
+	Len int
+}
+
+type cacheKey struct {
+	name string
+	hash [sha256.Size]byte
+}
+
+type cacheEntry struct {
+	key  cacheKey
+	tmpl *Template
+}
+
+type cacheCall struct {
+	wg   sync.WaitGroup
+	tmpl *Template
+	err  error
+}
+
+// NewCache returns a Cache holding at most size templates, which are compiled
+// with the given options. A size of zero or less means the cache is unbounded.
+func NewCache(size int, opts ...CompileOption) *Cache {
+	return &Cache{
+		size:     size,
+		opts:     opts,
+		lru:      list.New(),
+		entries:  make(map[cacheKey]*list.Element),
+		names:    make(map[string]cacheKey),
+		inflight: make(map[cacheKey]*cacheCall),
+	}
+}
+
+// Compile returns the compiled template for the given name and contents,
+// compiling it if it is not already in the cache. Only one template is kept
+// per name: compiling new contents for a name evicts the old template.
+// Compile errors are returned to every waiting caller but are not cached.
+func (c *Cache) Compile(name, contents string) (*Template, error) {
+	key := cacheKey{name: name, hash: sha256.Sum256([]byte(contents))}
+
+	c.mu.Lock()
+	if elem, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(elem)
+		c.stats.Hits++
+		c.mu.Unlock()
+		return elem.Value.(*cacheEntry).tmpl, nil
+	}
+	if call, ok := c.inflight[key]; ok {
+		c.stats.Hits++
+		c.mu.Unlock()
+		call.wg.Wait()
+		return call.tmpl, call.err
+	}
+	call := &cacheCall{}
+	call.wg.Add(1)
+	c.inflight[key] = call
+	c.stats.Misses++
+	c.mu.Unlock()
+
+	call.tmpl, call.err = Compile(contents, c.opts...)
+
+	c.mu.Lock()
+	delete(c.inflight, key)
+	if call.err == nil {
+		c.add(key, call.tmpl)
+	}
+	c.mu.Unlock()
+	call.wg.Done()
+
+	return call.tmpl, call.err
+}
+
+// Remove drops the template for name from the cache, if present.
+func (c *Cache) Remove(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if key, ok := c.names[name]; ok {
+		c.remove(c.entries[key])
+	}
+}
+
+// Stats returns a snapshot of the cache counters.
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Len = c.lru.Len()
+	return stats
+}
+
+// add inserts a template, evicting any stale template for the same name and
+// the least recently used templates beyond the cache size. c.mu must be held.
+func (c *Cache) add(key cacheKey, tmpl *Template) {
+	if old, ok := c.names[key.name]; ok && old != key {
+		c.remove(c.entries[old])
+		c.stats.Evictions++
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, tmpl: tmpl})
+	c.names[key.name] = key
+	for c.size > 0 && c.lru.Len() > c.size {
+		c.remove(c.lru.Back())
+		c.stats.Evictions++
+	}
+}
+
+// remove deletes elem from the cache. c.mu must be held.
+func (c *Cache) remove(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*cacheEntry)
+	delete(c.entries, entry.key)
+	delete(c.names, entry.key.name)
+}
@@ -0,0 +1,76 @@
+package mustache
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	c := NewCache(2)
+
+	a, err := c.Compile("a", "Hello, {{name}}!")
+	if !assert.NoError(t, err) {
+		return
+	}
+	a2, err := c.Compile("a", "Hello, {{name}}!")
+	if assert.NoError(t, err) {
+		assert.True(t, a == a2, "expected the cached template")
+	}
+	assert.Equal(t, CacheStats{Hits: 1, Misses: 1, Len: 1}, c.Stats())
+
+	// New contents for a name replace the old template.
+	a3, err := c.Compile("a", "Goodbye, {{name}}!")
+	if assert.NoError(t, err) {
+		assert.False(t, a == a3, "expected a new template")
+	}
+	assert.Equal(t, CacheStats{Hits: 1, Misses: 2, Evictions: 1, Len: 1}, c.Stats())
+
+	// The least recently used template is evicted.
+	_, err = c.Compile("b", "{{b}}")
+	assert.NoError(t, err)
+	_, err = c.Compile("a", "Goodbye, {{name}}!")
+	assert.NoError(t, err)
+	_, err = c.Compile("c", "{{c}}")
+	assert.NoError(t, err)
+	assert.Equal(t, CacheStats{Hits: 2, Misses: 4, Evictions: 2, Len: 2}, c.Stats())
+	_, err = c.Compile("a", "Goodbye, {{name}}!")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), c.Stats().Hits)
+
+	c.Remove("a")
+	c.Remove("missing")
+	assert.Equal(t, 1, c.Stats().Len)
+}
+
+func TestCacheErrors(t *testing.T) {
+	c := NewCache(0)
+	for i := 0; i < 2; i++ {
+		_, err := c.Compile("bad", "{{/a}}")
+		assert.Error(t, err)
+	}
+	assert.Equal(t, CacheStats{Misses: 2}, c.Stats())
+}
+
+func TestCacheConcurrentCompile(t *testing.T) {
+	c := NewCache(10, WithFilters(nil))
+	const goroutines = 100
+	templates := make([]*Template, goroutines)
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			templates[i], _ = c.Compile("page", "{{#items}}{{name | upper}}{{/items}}")
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, CacheStats{Hits: goroutines - 1, Misses: 1, Len: 1}, c.Stats())
+	for i := range templates {
+		if assert.NotNil(t, templates[i]) {
+			assert.True(t, templates[0] == templates[i], "expected a single compilation")
+		}
+	}
+}
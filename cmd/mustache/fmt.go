@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/cbroglie/go-mustache"
+)
+
+// templateExt is the extension of the files processed when a directory is
+// given as an argument.
+const templateExt = ".mustache"
+
+// runFmt implements "mustache fmt", which is modeled on gofmt.
+func runFmt(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	write := flags.Bool("w", false, "write result to (source) file instead of stdout")
+	diff := flags.Bool("d", false, "display diffs instead of rewriting files")
+	options := extensionFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: mustache fmt [-w] [-d] [-filters] [-else] [-parent-access] [path ...]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintf(stderr, "Formats the given files, or the %s files in the given directories.\n", templateExt)
+		fmt.Fprintln(stderr, "With no paths, formats standard input.")
+		fmt.Fprintln(stderr)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	f := &fmtCommand{write: *write, diff: *diff, opts: options(), stdout: stdout}
+	if flags.NArg() == 0 {
+		if *write {
+			fmt.Fprintln(stderr, "mustache fmt: cannot use -w with standard input")
+			return 2
+		}
+		if err := f.process("<standard input>", stdin, false); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+		return 0
+	}
+
+	status := 0
+	for _, root := range flags.Args() {
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			// Files named explicitly are formatted whatever their extension.
+			if info.IsDir() || (path != root && filepath.Ext(path) != templateExt) {
+				return nil
+			}
+			in, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer in.Close()
+			if err := f.process(path, in, true); err != nil {
+				fmt.Fprintln(stderr, err)
+				status = 2
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			status = 2
+		}
+	}
+	return status
+}
+
+type fmtCommand struct {
+	write  bool
+	diff   bool
+	opts   []mustache.CompileOption
+	stdout io.Writer
+}
+
+func (f *fmtCommand) process(name string, in io.Reader, isFile bool) error {
+	src, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	res, err := mustache.Format(src, f.opts...)
+	if err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+
+	if !f.write && !f.diff {
+		_, err = f.stdout.Write(res)
+		return err
+	}
+	if bytes.Equal(src, res) {
+		return nil
+	}
+	if f.diff {
+		d, err := diff(name, src, res)
+		if err != nil {
+			return fmt.Errorf("computing diff: %v", err)
+		}
+		fmt.Fprintf(f.stdout, "diff -u %s.orig %s\n", filepath.ToSlash(name), filepath.ToSlash(name))
+		f.stdout.Write(d)
+	}
+	if f.write && isFile {
+		info, err := os.Stat(name)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(name, res, info.Mode().Perm())
+	}
+	return nil
+}
+
+// diff returns the unified diff of b1 and b2, using the system diff command
+// as gofmt does.
+func diff(name string, b1, b2 []byte) ([]byte, error) {
+	f1, err := writeTempFile("mustache-fmt", b1)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f1)
+	f2, err := writeTempFile("mustache-fmt", b2)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f2)
+
+	data, err := exec.Command("diff", "-u", "--label", name+".orig", "--label", name, f1, f2).CombinedOutput()
+	if len(data) > 0 {
+		// diff exits with a non-zero status when the files don't match.
+		// Ignore that failure as long as we get output.
+		return data, nil
+	}
+	return data, err
+}
+
+func writeTempFile(prefix string, data []byte) (string, error) {
+	file, err := os.CreateTemp("", prefix)
+	if err != nil {
+		return "", err
+	}
+	_, err = file.Write(data)
+	if err1 := file.Close(); err == nil {
+		err = err1
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cbroglie/go-mustache/lint"
+)
+
+var lintFormats = map[string]func(io.Writer, []lint.Diagnostic) error{
+	"text":  lint.WriteText,
+	"json":  lint.WriteJSON,
+	"sarif": lint.WriteSARIF,
+}
+
+// runLint implements "mustache lint". The exit status is 1 if problems were
+// found and 2 on errors.
+func runLint(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	format := flags.String("format", "text", "output format: text, json or sarif")
+	enable := flags.String("enable", "", "comma-separated `rules` to enable")
+	disable := flags.String("disable", "", "comma-separated `rules` to disable")
+	list := flags.Bool("rules", false, "list the rules and exit")
+	options := extensionFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: mustache lint [-format text|json|sarif] [-enable rules] [-disable rules] [-filters] [-else] [-parent-access] [path ...]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintf(stderr, "Checks the given files, or the %s files in the given directories.\n", templateExt)
+		fmt.Fprintln(stderr, "Partial tags are resolved against the other checked templates, named")
+		fmt.Fprintln(stderr, "by their path relative to the directory, without the extension.")
+		fmt.Fprintln(stderr)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *list {
+		for _, r := range lint.Rules {
+			state := "on"
+			if !r.Default {
+				state = "off"
+			}
+			fmt.Fprintf(stdout, "%-18s %-3s %s\n", r.Name, state, r.Description)
+		}
+		return 0
+	}
+	write, ok := lintFormats[*format]
+	if !ok {
+		fmt.Fprintf(stderr, "mustache lint: unknown format %q\n", *format)
+		return 2
+	}
+	config := &lint.Config{Rules: make(map[string]bool), Options: options()}
+	for _, r := range splitList(*enable) {
+		config.Rules[r] = true
+	}
+	for _, r := range splitList(*disable) {
+		config.Rules[r] = false
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	status := 0
+	var files []lint.File
+	for _, root := range flags.Args() {
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			// Files named explicitly are checked whatever their extension.
+			if info.IsDir() || (path != root && filepath.Ext(path) != templateExt) {
+				return nil
+			}
+			name := filepath.Base(path)
+			if path != root {
+				if name, err = filepath.Rel(root, path); err != nil {
+					return err
+				}
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			files = append(files, lint.File{
+				Name:     filepath.ToSlash(strings.TrimSuffix(name, filepath.Ext(name))),
+				Path:     path,
+				Contents: string(data),
+			})
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			status = 2
+		}
+	}
+
+	diagnostics, err := lint.Lint(files, config)
+	if err != nil {
+		fmt.Fprintf(stderr, "mustache lint: %v\n", err)
+		return 2
+	}
+	if err := write(stdout, diagnostics); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	if status == 0 && len(diagnostics) > 0 {
+		status = 1
+	}
+	return status
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
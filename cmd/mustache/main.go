@@ -0,0 +1,73 @@
+// Command mustache provides tools for working with mustache templates.
+//
+// Usage:
+//
+//	mustache fmt [-w] [-d] [-filters] [-else] [-parent-access] [path ...]
+//	mustache lint [-format text|json|sarif] [-enable rules] [-disable rules]
+//	              [-filters] [-else] [-parent-access] [path ...]
+//
+// Run "mustache <command> -h" for help with a command.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/cbroglie/go-mustache"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the command given by args and returns the exit status.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+	switch args[0] {
+	case "fmt":
+		return runFmt(args[1:], stdin, stdout, stderr)
+	case "lint":
+		return runLint(args[1:], stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		usage(stdout)
+		return 0
+	}
+	fmt.Fprintf(stderr, "mustache: unknown command %q\n", args[0])
+	usage(stderr)
+	return 2
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: mustache <command> [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	fmt.Fprintln(w, "  fmt   format templates")
+	fmt.Fprintln(w, "  lint  check templates for common problems")
+}
+
+// extensionFlags defines the flags enabling the syntax extensions of the
+// parser. The returned function gives the options for the flags that are set,
+// once the flags are parsed.
+func extensionFlags(flags *flag.FlagSet) func() []mustache.CompileOption {
+	filters := flags.Bool("filters", false, "enable filter pipes, e.g. {{name | upper}}")
+	elseDivider := flags.Bool("else", false, "enable else dividers, {{else}} or {{^}}")
+	parentAccess := flags.Bool("parent-access", false, "enable parent access, e.g. {{../name}}")
+	return func() []mustache.CompileOption {
+		var opts []mustache.CompileOption
+		if *filters {
+			opts = append(opts, mustache.WithFilters(nil))
+		}
+		if *elseDivider {
+			opts = append(opts, mustache.WithElseDivider())
+		}
+		if *parentAccess {
+			opts = append(opts, mustache.WithParentAccess())
+		}
+		return opts
+	}
+}
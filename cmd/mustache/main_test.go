@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func runCommand(stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	status := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return status, stdout.String(), stderr.String()
+}
+
+func TestRun(t *testing.T) {
+	status, _, stderr := runCommand("")
+	assert.Equal(t, 2, status)
+	assert.Contains(t, stderr, "usage:")
+
+	status, _, stderr = runCommand("", "frobnicate")
+	assert.Equal(t, 2, status)
+	assert.Contains(t, stderr, `unknown command "frobnicate"`)
+}
+
+func TestFmtStdin(t *testing.T) {
+	status, stdout, _ := runCommand("Hello {{ name }}!", "fmt")
+	assert.Equal(t, 0, status)
+	assert.Equal(t, "Hello {{name}}!", stdout)
+
+	status, _, stderr := runCommand("{{#a}}", "fmt")
+	assert.Equal(t, 2, status)
+	assert.Contains(t, stderr, "<standard input>")
+
+	status, _, _ = runCommand("", "fmt", "-w")
+	assert.Equal(t, 2, status)
+
+	// Syntax extensions must be enabled by flags.
+	pipes := "{{#a}}{{ ../name|truncate 3 }}{{ else }}none{{/a}}"
+	status, _, _ = runCommand(pipes, "fmt")
+	assert.Equal(t, 2, status)
+	status, stdout, stderr = runCommand(pipes, "fmt", "-filters", "-else", "-parent-access")
+	assert.Equal(t, 0, status, stderr)
+	assert.Equal(t, "{{#a}}{{../name | truncate 3}}{{else}}none{{/a}}", stdout)
+}
+
+func TestFmtFiles(t *testing.T) {
+	dir := t.TempDir()
+	page := filepath.Join(dir, "page.mustache")
+	other := filepath.Join(dir, "notes.txt")
+	for _, path := range []string{page, other} {
+		if err := os.WriteFile(path, []byte("{{# a }}\n{{ b }}\n{{/ a }}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	status, stdout, stderr := runCommand("", "fmt", "-d", dir)
+	assert.Equal(t, 0, status, stderr)
+	assert.Contains(t, stdout, "-{{# a }}\n")
+	assert.Contains(t, stdout, "+{{#a}}\n")
+	assert.NotContains(t, stdout, "notes.txt")
+
+	status, stdout, _ = runCommand("", "fmt", "-w", dir, other)
+	assert.Equal(t, 0, status)
+	assert.Equal(t, "", stdout)
+	for _, path := range []string{page, other} {
+		data, err := os.ReadFile(path)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "{{#a}}\n{{b}}\n{{/a}}\n", string(data))
+		}
+	}
+
+	status, stdout, _ = runCommand("", "fmt", "-d", page)
+	assert.Equal(t, 0, status)
+	assert.Equal(t, "", stdout)
+
+	status, _, _ = runCommand("", "fmt", filepath.Join(dir, "missing.mustache"))
+	assert.Equal(t, 2, status)
+}
+
+func TestLint(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"page.mustache":             "{{>partials/_header}}{{>nav}}",
+		"partials/_header.mustache": "<h1>{{{title}}}</h1>",
+		"notes.txt":                 "{{>missing}}",
+	}
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	status, stdout, stderr := runCommand("", "lint", dir)
+	assert.Equal(t, 1, status, stderr)
+	assert.Equal(t, filepath.Join(dir, "page.mustache")+`:1:22: partial "nav" not found (missing-partial)`+"\n"+
+		filepath.Join(dir, "partials", "_header.mustache")+`:1:5: unescaped variable "title" in HTML template (unescaped-html)`+"\n", stdout)
+
+	status, stdout, _ = runCommand("", "lint", "-disable", "missing-partial, unescaped-html", dir)
+	assert.Equal(t, 0, status)
+	assert.Equal(t, "", stdout)
+
+	status, stdout, _ = runCommand("", "lint", "-format", "sarif", "-disable", "unescaped-html", dir)
+	assert.Equal(t, 1, status)
+	assert.Contains(t, stdout, `"ruleId": "missing-partial"`)
+
+	status, _, stderr = runCommand("", "lint", "-enable", "bogus", dir)
+	assert.Equal(t, 2, status)
+	assert.Contains(t, stderr, `unknown rule "bogus"`)
+
+	status, _, stderr = runCommand("", "lint", "-format", "xml", dir)
+	assert.Equal(t, 2, status)
+	assert.Contains(t, stderr, `unknown format "xml"`)
+
+	pipes := filepath.Join(dir, "pipes.txt")
+	if err := os.WriteFile(pipes, []byte("{{#a}}{{../name | upper}}{{else}}none{{/a}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	status, stdout, _ = runCommand("", "lint", pipes)
+	assert.Equal(t, 1, status)
+	assert.Contains(t, stdout, "(syntax)")
+	status, stdout, stderr = runCommand("", "lint", "-filters", "-else", "-parent-access", pipes)
+	assert.Equal(t, 0, status, stderr)
+	assert.Equal(t, "", stdout)
+
+	status, stdout, _ = runCommand("", "lint", "-rules")
+	assert.Equal(t, 0, status)
+	assert.Contains(t, stdout, "tag-spacing")
+}
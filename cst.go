@@ -0,0 +1,165 @@
+package mustache
+
+import "strings"
+
+// CSTKind identifies the kind of a CSTNode.
+type CSTKind uint
+
+// Defines for the possible CSTKind values.
+const (
+	CSTText       CSTKind = iota // static text
+	CSTWhitespace                // whitespace dropped around a standalone tag
+	CSTVariable                  // a variable tag, escaped or not
+	CSTPartial                   // a partial tag
+	CSTComment                   // a comment tag
+	CSTSection                   // a section, containing its opening and closing tags
+	CSTOpen                      // the opening tag of a section or inverted section
+	CSTElse                      // an else divider within a section
+	CSTClose                     // the closing tag of a section
+)
+
+var cstKinds = map[TokenType]CSTKind{
+	Variable:          CSTVariable,
+	UnescapedVariable: CSTVariable,
+	Partial:           CSTPartial,
+	comment:           CSTComment,
+	Section:           CSTOpen,
+	InvertedSection:   CSTOpen,
+	closeSection:      CSTClose,
+}
+
+// CSTNode is a node of a concrete syntax tree. Unlike the token tree of a
+// Template, the concrete syntax tree keeps every byte of the source, including
+// comments, whitespace inside tags, closing tags and the whitespace dropped
+// around standalone tags.
+type CSTNode struct {
+	Kind CSTKind
+	// Source is the exact source text of a leaf node. It is empty for
+	// sections, whose text is held by their children.
+	Source string
+	// Start is the position of the node in the template source.
+	Start Position
+	// Sigil is the character identifying the type of a tag ("#", "^", "/",
+	// "!", ">" or "{"), or empty for variables and non-tag nodes.
+	Sigil string
+	// Content is the text of a tag between the sigil and the closing
+	// delimiter, without surrounding whitespace.
+	Content string
+	// Token is the token produced by a variable, partial or section node, or
+	// the section closed by a closing tag.
+	Token Token
+	// Children holds the nodes of a section: its opening tag, its contents and
+	// its closing tag.
+	Children []*CSTNode
+}
+
+// String returns the source text of the node.
+func (n *CSTNode) String() string {
+	if n.Kind != CSTSection {
+		return n.Source
+	}
+	var b strings.Builder
+	for _, child := range n.Children {
+		b.WriteString(child.String())
+	}
+	return b.String()
+}
+
+// CST is a lossless concrete syntax tree of a template, for tools which
+// rewrite templates. Printing it with String reproduces the source exactly.
+type CST struct {
+	Nodes []*CSTNode
+}
+
+// String returns the source text of the template.
+func (c *CST) String() string {
+	var b strings.Builder
+	for _, n := range c.Nodes {
+		b.WriteString(n.String())
+	}
+	return b.String()
+}
+
+// ParseCST parses a template into a concrete syntax tree. It reports the same
+// errors as Compile with the same options.
+func ParseCST(contents string, opts ...CompileOption) (*CST, error) {
+	p := &parser{cst: &cstBuilder{}}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if err := p.parse(contents); err != nil {
+		return nil, err
+	}
+	p.cst.text(len(contents))
+	return &CST{Nodes: p.cst.nodes}, nil
+}
+
+// cstBuilder assembles a CST from the tags reported by the parser. Any source
+// between tags is text.
+type cstBuilder struct {
+	scanner  *stringScanner
+	nodes    []*CSTNode
+	sections []*CSTNode
+	pos      int // end of the last node added
+}
+
+// tag adds a tag spanning [start, end) to the tree. The whitespace in
+// [padStart, start) and [end, wsEnd) is dropped from the output if dropped is
+// set (padding) or wsEnd is past end (the rest of a standalone line).
+func (b *cstBuilder) tag(kind CSTKind, token Token, padStart, start, contentStart, contentEnd, end, wsEnd int, dropped bool) {
+	if dropped && padStart < start {
+		b.text(padStart)
+		b.add(b.leaf(CSTWhitespace, padStart, start))
+	}
+	b.text(start)
+
+	n := b.leaf(kind, start, end)
+	n.Token = token
+	n.Content = strings.TrimSpace(b.scanner.input[contentStart:contentEnd])
+	if sigil := b.scanner.input[start+len("{{")]; strings.IndexByte("#^/!>{", sigil) >= 0 {
+		n.Sigil = string(sigil)
+	}
+	switch kind {
+	case CSTOpen:
+		s := &CSTNode{Kind: CSTSection, Start: n.Start, Token: token}
+		b.add(s)
+		b.sections = append(b.sections, s)
+		b.add(n)
+	case CSTClose:
+		b.add(n)
+		b.sections = b.sections[:len(b.sections)-1]
+	default:
+		b.add(n)
+	}
+
+	if wsEnd > end {
+		b.add(b.leaf(CSTWhitespace, end, wsEnd))
+	}
+}
+
+// text adds any source between the last node and end as a text node.
+func (b *cstBuilder) text(end int) {
+	if end > b.pos {
+		b.add(b.leaf(CSTText, b.pos, end))
+	}
+}
+
+func (b *cstBuilder) leaf(kind CSTKind, start, end int) *CSTNode {
+	return &CSTNode{
+		Kind:   kind,
+		Source: b.scanner.input[start:end],
+		Start:  b.scanner.Position(start),
+	}
+}
+
+func (b *cstBuilder) add(n *CSTNode) {
+	if n.Kind != CSTSection {
+		b.pos = n.Start.Offset + len(n.Source)
+	}
+	if len(b.sections) > 0 {
+		s := b.sections[len(b.sections)-1]
+		s.Children = append(s.Children, n)
+		return
+	}
+	b.nodes = append(b.nodes, n)
+}
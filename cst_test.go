@@ -0,0 +1,67 @@
+package mustache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSTRoundTrip(t *testing.T) {
+	templates := []string{
+		"",
+		"This is an example string",
+		"Welcome to {{ place }}!",
+		"Welcome to {{{place }}}!",
+		"12{{!comment !}}34",
+		"12\n{{! comment }}\n34",
+		"Begin.\n  {{!\n    Something's going on here...\n  }}\nEnd.",
+		"{{^c}}{{#a}}Name: {{b}}.{{/a}}{{/c}}",
+		"  {{#a}}  \r\n  {{ b }}\n  {{/a}}\n",
+		"{{#items}}\n  {{ name | upper | truncate 20 }}\n{{ else }}\n  None\n{{/items}}",
+		"  {{>partial}}",
+		"{{#a}}{{../b}}{{/a}}",
+	}
+	for _, template := range templates {
+		cst, err := ParseCST(template, WithFilters(nil), WithElseDivider(), WithParentAccess())
+		if assert.NoError(t, err, template) {
+			assert.Equal(t, template, cst.String())
+		}
+	}
+}
+
+func TestCST(t *testing.T) {
+	cst, err := ParseCST("A\n  {{! note }}\n{{#a}}\n {{ b }}{{/a}}", WithElseDivider())
+	if !assert.NoError(t, err) || !assert.Len(t, cst.Nodes, 5) {
+		return
+	}
+	assert.Equal(t, &CSTNode{Kind: CSTText, Source: "A\n", Start: Position{0, 1, 1}}, cst.Nodes[0])
+	assert.Equal(t, &CSTNode{Kind: CSTWhitespace, Source: "  ", Start: Position{2, 2, 1}}, cst.Nodes[1])
+	assert.Equal(t, &CSTNode{Kind: CSTComment, Source: "{{! note }}", Start: Position{4, 2, 3}, Sigil: "!", Content: "note"}, cst.Nodes[2])
+	assert.Equal(t, &CSTNode{Kind: CSTWhitespace, Source: "\n", Start: Position{15, 2, 14}}, cst.Nodes[3])
+
+	s := cst.Nodes[4]
+	assert.Equal(t, CSTSection, s.Kind)
+	assert.Equal(t, "a", s.Token.Name())
+	if assert.Len(t, s.Children, 5) {
+		assert.Equal(t, CSTOpen, s.Children[0].Kind)
+		assert.Equal(t, "#", s.Children[0].Sigil)
+		assert.Equal(t, "a", s.Children[0].Content)
+		assert.Equal(t, CSTWhitespace, s.Children[1].Kind)
+		assert.Equal(t, &CSTNode{Kind: CSTText, Source: " ", Start: Position{23, 4, 1}}, s.Children[2])
+		assert.Equal(t, CSTVariable, s.Children[3].Kind)
+		assert.Equal(t, "", s.Children[3].Sigil)
+		assert.Equal(t, "b", s.Children[3].Content)
+		assert.Equal(t, Variable, s.Children[3].Token.Type())
+		assert.Equal(t, "b", s.Children[3].Token.Name())
+		assert.Equal(t, CSTClose, s.Children[4].Kind)
+		assert.True(t, s.Children[4].Token == s.Token)
+	}
+
+	cst, err = ParseCST("{{#a}}A{{^}}B{{/a}}", WithElseDivider())
+	if assert.NoError(t, err) && assert.Len(t, cst.Nodes, 1) && assert.Len(t, cst.Nodes[0].Children, 5) {
+		assert.Equal(t, CSTElse, cst.Nodes[0].Children[2].Kind)
+	}
+
+	_, err = ParseCST("{{#a}}")
+	assert.Error(t, err)
+}
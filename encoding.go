@@ -0,0 +1,218 @@
+package mustache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+)
+
+// encodingVersion is the version of the serialized template format. It must be
+// incremented whenever encodedTemplate or encodedToken change, so that stale
+// caches are rejected rather than decoded incorrectly.
+const encodingVersion = 2
+
+// encodingMagic prefixes the binary form of a template.
+const encodingMagic = "mustache"
+
+type encodedTemplate struct {
+	Version int            `json:"version"`
+	Filters bool           `json:"filters,omitempty"`
+	Tokens  []encodedToken `json:"tokens"`
+}
+
+type encodedToken struct {
+	Type       TokenType      `json:"type"`
+	Source     encodedSource  `json:"source"`
+	Close      *encodedSource `json:"close,omitempty"`
+	Value      string         `json:"value,omitempty"`
+	Name       string         `json:"name,omitempty"`
+	Parents    int            `json:"parents,omitempty"`
+	Filters    []FilterCall   `json:"filters,omitempty"`
+	Tokens     []encodedToken `json:"tokens,omitempty"`
+	HasElse    bool           `json:"hasElse,omitempty"`
+	ElseTokens []encodedToken `json:"elseTokens,omitempty"`
+}
+
+type encodedSource struct {
+	Start      Position `json:"start"`
+	End        Position `json:"end"`
+	Raw        string   `json:"raw,omitempty"`
+	Standalone bool     `json:"standalone,omitempty"`
+}
+
+func encodeSource(s source) encodedSource {
+	return encodedSource{Start: s.start, End: s.end, Raw: s.raw, Standalone: s.standalone}
+}
+
+func (e encodedSource) decode() source {
+	return source{start: e.Start, end: e.End, raw: e.Raw, standalone: e.Standalone}
+}
+
+// MarshalBinary encodes the compiled template so that it can be cached and
+// later restored with UnmarshalBinary or UnmarshalTemplate without parsing it
+// again. The encoding is tagged with a format version.
+func (t *Template) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(encodingMagic)
+	var version [binary.MaxVarintLen64]byte
+	buf.Write(version[:binary.PutUvarint(version[:], encodingVersion)])
+	if err := gob.NewEncoder(&buf).Encode(t.encode()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary restores a template encoded by MarshalBinary. It must only be
+// called on a new Template. Templates using filters are restored with the
+// builtin filters only; use UnmarshalTemplate to supply custom filters.
+func (t *Template) UnmarshalBinary(data []byte) error {
+	tmpl, err := UnmarshalTemplate(data)
+	if err != nil {
+		return err
+	}
+	*t = *tmpl
+	return nil
+}
+
+// MarshalJSON encodes the compiled template as JSON. Like MarshalBinary, the
+// encoding is tagged with a format version.
+func (t *Template) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.encode())
+}
+
+// UnmarshalJSON restores a template encoded by MarshalJSON. It must only be
+// called on a new Template. Templates using filters are restored with the
+// builtin filters only; use UnmarshalTemplate to supply custom filters.
+func (t *Template) UnmarshalJSON(data []byte) error {
+	tmpl, err := UnmarshalTemplate(data)
+	if err != nil {
+		return err
+	}
+	*t = *tmpl
+	return nil
+}
+
+// UnmarshalTemplate restores a template encoded by MarshalBinary or
+// MarshalJSON. The options which the template was compiled with should be
+// given again; in particular WithFilters supplies the filter functions, which
+// cannot be encoded. Data encoded with a different format version is rejected.
+func UnmarshalTemplate(data []byte, opts ...CompileOption) (*Template, error) {
+	var enc encodedTemplate
+	if bytes.HasPrefix(data, []byte(encodingMagic)) {
+		r := bytes.NewReader(data[len(encodingMagic):])
+		version, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid template encoding: %v", err)
+		}
+		if version != encodingVersion {
+			return nil, fmt.Errorf("Unsupported template encoding version %d", version)
+		}
+		if err := gob.NewDecoder(r).Decode(&enc); err != nil {
+			return nil, fmt.Errorf("Invalid template encoding: %v", err)
+		}
+	} else {
+		if err := json.Unmarshal(data, &enc); err != nil {
+			return nil, fmt.Errorf("Invalid template encoding: %v", err)
+		}
+		if enc.Version != encodingVersion {
+			return nil, fmt.Errorf("Unsupported template encoding version %d", enc.Version)
+		}
+	}
+
+	p := &parser{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if enc.Filters && p.funcs == nil {
+		WithFilters(nil)(p)
+	}
+	tokens, err := p.decodeTokens(enc.Tokens)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{tokens: tokens, funcs: p.funcs}, nil
+}
+
+func (t *Template) encode() *encodedTemplate {
+	return &encodedTemplate{
+		Version: encodingVersion,
+		Filters: t.funcs != nil,
+		Tokens:  encodeTokens(t.tokens),
+	}
+}
+
+func encodeTokens(tokens []Token) []encodedToken {
+	enc := make([]encodedToken, len(tokens))
+	for i, token := range tokens {
+		enc[i].Type = token.Type()
+		switch token := token.(type) {
+		case *text:
+			enc[i].Source = encodeSource(token.source)
+			enc[i].Value = token.value
+		case *variable:
+			enc[i].Source = encodeSource(token.source)
+			enc[i].Name = token.name
+			enc[i].Parents = token.parents
+			enc[i].Filters = token.filters
+		case *section:
+			closeSource := encodeSource(token.close)
+			enc[i].Source = encodeSource(token.source)
+			enc[i].Close = &closeSource
+			enc[i].Name = token.name
+			enc[i].Parents = token.parents
+			enc[i].Tokens = encodeTokens(token.tokens)
+			enc[i].HasElse = token.elseTokens != nil
+			enc[i].ElseTokens = encodeTokens(token.elseTokens)
+		case *partial:
+			enc[i].Source = encodeSource(token.source)
+			enc[i].Name = token.name
+		}
+	}
+	return enc
+}
+
+// decodeTokens rebuilds a token tree, checking that any filters used are
+// available to the parser.
+func (p *parser) decodeTokens(enc []encodedToken) ([]Token, error) {
+	tokens := make([]Token, 0, len(enc))
+	for _, e := range enc {
+		switch e.Type {
+		case Text:
+			tokens = append(tokens, &text{source: e.Source.decode(), value: e.Value})
+		case Variable, UnescapedVariable:
+			for _, f := range e.Filters {
+				if err := p.checkFilter(f); err != nil {
+					return nil, err
+				}
+			}
+			tokens = append(tokens, &variable{source: e.Source.decode(), name: e.Name, parents: e.Parents, escape: e.Type == Variable, filters: e.Filters})
+		case Section, InvertedSection:
+			s := newSection()
+			s.source = e.Source.decode()
+			if e.Close != nil {
+				s.close = e.Close.decode()
+			}
+			s.name = e.Name
+			s.parents = e.Parents
+			s.inverted = e.Type == InvertedSection
+			children, err := p.decodeTokens(e.Tokens)
+			if err != nil {
+				return nil, err
+			}
+			s.tokens = children
+			if e.HasElse {
+				if s.elseTokens, err = p.decodeTokens(e.ElseTokens); err != nil {
+					return nil, err
+				}
+			}
+			tokens = append(tokens, s)
+		case Partial:
+			tokens = append(tokens, &partial{source: e.Source.decode(), name: e.Name})
+		default:
+			return nil, fmt.Errorf("Invalid token type %d", e.Type)
+		}
+	}
+	return tokens, nil
+}
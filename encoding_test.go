@@ -0,0 +1,89 @@
+package mustache
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const encodingTemplate = "Hello {{../name | upper}}{{#items}}{{>item}}{{{raw}}}{{^}}None{{/items}}{{^a}}{{#b}}{{/b}}{{/a}}\n"
+
+func TestMarshalBinary(t *testing.T) {
+	tmpl, err := Compile(encodingTemplate, WithFilters(nil), WithElseDivider())
+	if !assert.NoError(t, err) {
+		return
+	}
+	data, err := tmpl.MarshalBinary()
+	if !assert.NoError(t, err) {
+		return
+	}
+	var restored Template
+	if assert.NoError(t, restored.UnmarshalBinary(data)) {
+		assert.Equal(t, tmpl.Tokens(), restored.Tokens())
+		assert.Len(t, restored.funcs, len(builtinFilters))
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	tmpl, err := Compile(strings.Replace(encodingTemplate, "../name | upper", "name", 1), WithElseDivider())
+	if !assert.NoError(t, err) {
+		return
+	}
+	data, err := tmpl.MarshalJSON()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, bytes.HasPrefix(data, []byte(`{"version":2,`)), string(data))
+	var restored Template
+	if assert.NoError(t, restored.UnmarshalJSON(data)) {
+		assert.Equal(t, tmpl, &restored)
+	}
+}
+
+func TestUnmarshalTemplate(t *testing.T) {
+	funcs := FuncMap{"shout": upperFilter}
+	tmpl, err := Compile("{{name | shout}}", WithFilters(funcs))
+	if !assert.NoError(t, err) {
+		return
+	}
+	data, err := tmpl.MarshalBinary()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var restored Template
+	assert.Error(t, restored.UnmarshalBinary(data))
+	restoredPtr, err := UnmarshalTemplate(data, WithFilters(funcs))
+	if assert.NoError(t, err) {
+		assert.Equal(t, tmpl.Tokens(), restoredPtr.Tokens())
+		assert.Contains(t, restoredPtr.funcs, "shout")
+	}
+}
+
+func TestUnmarshalTemplateErrors(t *testing.T) {
+	tmpl, err := Compile("{{name}}")
+	if !assert.NoError(t, err) {
+		return
+	}
+	data, err := tmpl.MarshalBinary()
+	if !assert.NoError(t, err) {
+		return
+	}
+	stale := append([]byte(encodingMagic), 0)
+	stale = append(stale, data[len(encodingMagic)+1:]...)
+
+	tests := [][]byte{
+		stale,
+		data[:len(data)-1],
+		[]byte(encodingMagic),
+		[]byte(`{"version":1,"tokens":[]}`),
+		[]byte(`{"version":2,"tokens":[{"type":42}]}`),
+		[]byte(`not a template`),
+	}
+	for _, data := range tests {
+		_, err := UnmarshalTemplate(data)
+		assert.Error(t, err, string(data))
+	}
+}
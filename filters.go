@@ -0,0 +1,204 @@
+package mustache
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Filter transforms a value in a filter chain such as
+// `{{ name | truncate 20 }}`. The value is the result of the lookup or of the
+// previous filter in the chain, and args holds the literal arguments which
+// followed the filter name in the tag.
+type Filter func(value interface{}, args ...string) (interface{}, error)
+
+// FuncMap maps filter names to their implementations. It is modeled on the
+// FuncMap type of text/template.
+type FuncMap map[string]Filter
+
+// FilterCall represents a single filter invocation in a variable tag.
+type FilterCall struct {
+	// Name is the name of the filter, as registered in the FuncMap.
+	Name string `json:"name"`
+	// Args are the literal arguments passed to the filter. Quoted arguments
+	// are unquoted.
+	Args []string `json:"args,omitempty"`
+}
+
+// WithFilters enables the filter pipe syntax in variable tags, e.g.
+// `{{ name | upper | truncate 20 }}`. The builtin filters (upper, lower, trim,
+// truncate, default, date, number and json) are always available; funcs may
+// add to or override them. Filter names, and the number of arguments passed to
+// builtin filters which are not overridden, are checked when the template is
+// compiled. Other argument errors are reported by the filter when rendering.
+func WithFilters(funcs FuncMap) CompileOption {
+	return func(p *parser) {
+		p.funcs = make(FuncMap, len(builtinFilters)+len(funcs))
+		p.arities = make(map[string]arity, len(builtinArities))
+		for name, fn := range builtinFilters {
+			p.funcs[name] = fn
+			p.arities[name] = builtinArities[name]
+		}
+		for name, fn := range funcs {
+			p.funcs[name] = fn
+			delete(p.arities, name)
+		}
+	}
+}
+
+// checkFilter reports an error if a filter call names an unknown filter or
+// passes a builtin filter the wrong number of arguments.
+func (p *parser) checkFilter(call FilterCall) error {
+	if _, ok := p.funcs[call.Name]; !ok {
+		return fmt.Errorf("Unknown filter %q", call.Name)
+	}
+	a, ok := p.arities[call.Name]
+	if !ok || (len(call.Args) >= a.min && len(call.Args) <= a.max) {
+		return nil
+	}
+	if a.min == a.max {
+		return fmt.Errorf("Filter %q expects %d argument(s), got %d", call.Name, a.min, len(call.Args))
+	}
+	// The builtin filters with optional arguments take none or one.
+	return fmt.Errorf("Filter %q expects at most %d argument(s), got %d", call.Name, a.max, len(call.Args))
+}
+
+// arity is the range of argument counts accepted by a filter.
+type arity struct {
+	min, max int
+}
+
+var builtinFilters = FuncMap{
+	"upper":    upperFilter,
+	"lower":    lowerFilter,
+	"trim":     trimFilter,
+	"truncate": truncateFilter,
+	"default":  defaultFilter,
+	"date":     dateFilter,
+	"number":   numberFilter,
+	"json":     jsonFilter,
+}
+
+var builtinArities = map[string]arity{
+	"upper":    {0, 0},
+	"lower":    {0, 0},
+	"trim":     {0, 0},
+	"truncate": {1, 1},
+	"default":  {1, 1},
+	"date":     {0, 1},
+	"number":   {0, 1},
+	"json":     {0, 0},
+}
+
+func upperFilter(value interface{}, args ...string) (interface{}, error) {
+	return strings.ToUpper(fmt.Sprint(value)), nil
+}
+
+func lowerFilter(value interface{}, args ...string) (interface{}, error) {
+	return strings.ToLower(fmt.Sprint(value)), nil
+}
+
+func trimFilter(value interface{}, args ...string) (interface{}, error) {
+	return strings.TrimSpace(fmt.Sprint(value)), nil
+}
+
+// truncateFilter limits the value to the number of runes given by its only
+// argument.
+func truncateFilter(value interface{}, args ...string) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("truncate expects 1 argument, got %d", len(args))
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("truncate expects a non-negative length, got %q", args[0])
+	}
+	s := []rune(fmt.Sprint(value))
+	if len(s) <= n {
+		return string(s), nil
+	}
+	return string(s[:n]), nil
+}
+
+// defaultFilter replaces nil and empty string values with its only argument.
+func defaultFilter(value interface{}, args ...string) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("default expects 1 argument, got %d", len(args))
+	}
+	if value == nil {
+		return args[0], nil
+	}
+	if s, ok := value.(string); ok && s == "" {
+		return args[0], nil
+	}
+	return value, nil
+}
+
+// dateFilter formats a time.Time using the layout given as its argument, or
+// time.RFC3339 if no layout is given.
+func dateFilter(value interface{}, args ...string) (interface{}, error) {
+	layout := time.RFC3339
+	switch len(args) {
+	case 0:
+	case 1:
+		layout = args[0]
+	default:
+		return nil, fmt.Errorf("date expects at most 1 argument, got %d", len(args))
+	}
+	switch v := value.(type) {
+	case time.Time:
+		return v.Format(layout), nil
+	case *time.Time:
+		if v != nil {
+			return v.Format(layout), nil
+		}
+	}
+	return nil, fmt.Errorf("date expects a time.Time, got %T", value)
+}
+
+// numberFilter formats an integer or floating point value. An optional
+// argument sets the number of digits after the decimal point.
+func numberFilter(value interface{}, args ...string) (interface{}, error) {
+	prec := -1
+	switch len(args) {
+	case 0:
+	case 1:
+		p, err := strconv.Atoi(args[0])
+		if err != nil || p < 0 {
+			return nil, fmt.Errorf("number expects a non-negative precision, got %q", args[0])
+		}
+		prec = p
+	default:
+		return nil, fmt.Errorf("number expects at most 1 argument, got %d", len(args))
+	}
+
+	v := reflect.ValueOf(value)
+	var f float64
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if prec < 0 {
+			return strconv.FormatInt(v.Int(), 10), nil
+		}
+		f = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if prec < 0 {
+			return strconv.FormatUint(v.Uint(), 10), nil
+		}
+		f = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		f = v.Float()
+	default:
+		return nil, fmt.Errorf("number expects a numeric value, got %T", value)
+	}
+	return strconv.FormatFloat(f, 'f', prec, 64), nil
+}
+
+func jsonFilter(value interface{}, args ...string) (interface{}, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
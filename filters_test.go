@@ -0,0 +1,59 @@
+package mustache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type filterTest struct {
+	filter   string
+	value    interface{}
+	args     []string
+	expected interface{}
+}
+
+func TestBuiltinFilters(t *testing.T) {
+	date := time.Date(1975, time.December, 12, 14, 39, 0, 0, time.UTC)
+	tests := []filterTest{
+		{"upper", "Hello", nil, "HELLO"},
+		{"lower", "Hello", nil, "hello"},
+		{"trim", "  Hello\n", nil, "Hello"},
+		{"truncate", "Hello, world", []string{"5"}, "Hello"},
+		{"truncate", "héllo", []string{"2"}, "hé"},
+		{"truncate", "Hi", []string{"5"}, "Hi"},
+		{"default", nil, []string{"n/a"}, "n/a"},
+		{"default", "", []string{"n/a"}, "n/a"},
+		{"default", 0, []string{"n/a"}, 0},
+		{"date", date, nil, "1975-12-12T14:39:00Z"},
+		{"date", &date, []string{"2006-01-02"}, "1975-12-12"},
+		{"number", 42, nil, "42"},
+		{"number", uint8(7), []string{"2"}, "7.00"},
+		{"number", 3.14159, []string{"2"}, "3.14"},
+		{"number", 2.5, nil, "2.5"},
+		{"json", map[string]int{"a": 1}, nil, `{"a":1}`},
+	}
+	for _, test := range tests {
+		v, err := builtinFilters[test.filter](test.value, test.args...)
+		if assert.NoError(t, err, test.filter) {
+			assert.Equal(t, test.expected, v, test.filter)
+		}
+	}
+}
+
+func TestBuiltinFilterErrors(t *testing.T) {
+	tests := []filterTest{
+		{filter: "truncate", value: "Hello"},
+		{filter: "truncate", value: "Hello", args: []string{"five"}},
+		{filter: "default", value: nil},
+		{filter: "date", value: "1975-12-12"},
+		{filter: "number", value: "42"},
+		{filter: "number", value: 42, args: []string{"-1"}},
+		{filter: "json", value: func() {}},
+	}
+	for _, test := range tests {
+		_, err := builtinFilters[test.filter](test.value, test.args...)
+		assert.Error(t, err, test.filter)
+	}
+}
@@ -0,0 +1,156 @@
+package mustache
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// formatIndent is the indentation added for each level of section nesting.
+const formatIndent = "  "
+
+var (
+	htmlTag       = regexp.MustCompile(`(?i)<!doctype|</?[a-z][a-z0-9-]*[\s/>]`)
+	bareArg       = regexp.MustCompile(`^[^\s|}"]+$`)
+	lineEnding    = regexp.MustCompile(`\r?\n$`)
+	indentedKinds = map[CSTKind]bool{CSTOpen: true, CSTElse: true, CSTClose: true, CSTComment: true}
+)
+
+// Format returns the canonical formatting of a template. Tags are written
+// without padding (`{{name}}`, `{{#items}}`, `{{> partial}}` becomes
+// `{{>partial}}`), except single-line comments which are written as
+// `{{! comment }}`. Unless the template looks like HTML, section tags and
+// comments which stand alone on their line are indented by two spaces per
+// level of section nesting. Trailing whitespace is removed from those lines.
+//
+// Only whitespace which is dropped from the output is changed, so formatting
+// does not change how a template renders. Formatting is idempotent. The
+// options enable the same syntax extensions as for Compile.
+func Format(src []byte, opts ...CompileOption) ([]byte, error) {
+	cst, err := ParseCST(string(src), opts...)
+	if err != nil {
+		return nil, err
+	}
+	f := &formatter{indent: !LooksLikeHTML(string(src))}
+	f.flatten(cst.Nodes, 0)
+
+	var b strings.Builder
+	for i, leaf := range f.leaves {
+		n := leaf.node
+		switch n.Kind {
+		case CSTText:
+			b.WriteString(n.Source)
+		case CSTWhitespace:
+			if f.standalone(i - 1) {
+				// The rest of a standalone line.
+				b.WriteString(lineEnding.FindString(n.Source))
+			} else if f.isPadding(i) {
+				// The padding before a standalone tag.
+				b.WriteString(f.padding(i+1, n.Source))
+			} else {
+				b.WriteString(n.Source)
+			}
+		default:
+			if f.standalone(i) && (i == 0 || !f.isPadding(i-1)) {
+				b.WriteString(f.padding(i, ""))
+			}
+			b.WriteString(formatTag(n))
+		}
+	}
+	return []byte(b.String()), nil
+}
+
+// LooksLikeHTML reports whether a template appears to be HTML, i.e. contains
+// an HTML tag or doctype.
+func LooksLikeHTML(contents string) bool {
+	return htmlTag.MatchString(contents)
+}
+
+type formatter struct {
+	indent bool
+	leaves []formatLeaf
+}
+
+type formatLeaf struct {
+	node  *CSTNode
+	depth int // section nesting at the node; tags of a section are outside it
+}
+
+func (f *formatter) flatten(nodes []*CSTNode, depth int) {
+	for _, n := range nodes {
+		if n.Kind != CSTSection {
+			f.leaves = append(f.leaves, formatLeaf{node: n, depth: depth})
+			continue
+		}
+		for _, child := range n.Children {
+			switch child.Kind {
+			case CSTOpen, CSTElse, CSTClose:
+				f.leaves = append(f.leaves, formatLeaf{node: child, depth: depth})
+			default:
+				f.flatten([]*CSTNode{child}, depth+1)
+			}
+		}
+	}
+}
+
+// standalone reports whether leaf i is a section tag, else divider or comment
+// which is alone on its line.
+func (f *formatter) standalone(i int) bool {
+	if i < 0 || i+1 >= len(f.leaves) {
+		return false
+	}
+	if !indentedKinds[f.leaves[i].node.Kind] {
+		return false
+	}
+	next := f.leaves[i+1].node
+	return next.Kind == CSTWhitespace && lineEnding.MatchString(next.Source)
+}
+
+// isPadding reports whether leaf i is the whitespace before a standalone tag,
+// as opposed to the rest of the previous line.
+func (f *formatter) isPadding(i int) bool {
+	n := f.leaves[i].node
+	return n.Kind == CSTWhitespace && !lineEnding.MatchString(n.Source) && f.standalone(i+1)
+}
+
+// padding returns the indentation for standalone leaf i, or the original
+// padding if indentation is disabled.
+func (f *formatter) padding(i int, original string) string {
+	if !f.indent {
+		return original
+	}
+	return strings.Repeat(formatIndent, f.leaves[i].depth)
+}
+
+// formatTag returns the canonical text of a tag node.
+func formatTag(n *CSTNode) string {
+	switch n.Kind {
+	case CSTComment:
+		if strings.ContainsAny(n.Source, "\r\n") {
+			return n.Source
+		}
+		if n.Content == "" {
+			return "{{!}}"
+		}
+		return "{{! " + n.Content + " }}"
+	case CSTVariable:
+		content := n.Content
+		if filters := n.Token.Filters(); len(filters) > 0 {
+			content = strings.Repeat("../", n.Token.Parents()) + n.Token.Name()
+			for _, f := range filters {
+				content += " | " + f.Name
+				for _, arg := range f.Args {
+					if !bareArg.MatchString(arg) {
+						arg = strconv.Quote(arg)
+					}
+					content += " " + arg
+				}
+			}
+		}
+		if n.Sigil == "{" {
+			return "{{{" + content + "}}}"
+		}
+		return "{{" + content + "}}"
+	}
+	return "{{" + n.Sigil + n.Content + "}}"
+}
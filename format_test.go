@@ -0,0 +1,65 @@
+package mustache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type formatTest struct {
+	src      string
+	expected string
+}
+
+func TestFormat(t *testing.T) {
+	tests := []formatTest{
+		{"Hello {{ name }}!", "Hello {{name}}!"},
+		{"{{{ raw }}} {{> user }}", "{{{raw}}} {{>user}}"},
+		{"{{!note}} {{!  note !}}", "{{! note }} {{! note }}"},
+		{"{{!\n  multi\n}}", "{{!\n  multi\n}}"},
+		{"{{# a }}{{ b }}{{/ a }}", "{{#a}}{{b}}{{/a}}"},
+		{
+			"{{#a}}\n{{#b}}  \n  text\n      {{! note }}\n{{^c}}\r\n{{/c}}\n{{/b}}\n{{/a}}\n",
+			"{{#a}}\n  {{#b}}\n  text\n    {{! note }}\n    {{^c}}\r\n    {{/c}}\n  {{/b}}\n{{/a}}\n",
+		},
+		{
+			// Indentation is left alone in HTML.
+			"<ul>\n{{#items}}\n    <li>{{ name }}</li>\n    {{/items}}\n</ul>\n",
+			"<ul>\n{{#items}}\n    <li>{{name}}</li>\n    {{/items}}\n</ul>\n",
+		},
+		{
+			// Tags which are not alone on their line are not indented.
+			"{{#a}}\nx {{#b}}\n{{/b}} y\n{{/a}}",
+			"{{#a}}\nx {{#b}}\n{{/b}} y\n{{/a}}",
+		},
+	}
+	for _, test := range tests {
+		out, err := Format([]byte(test.src))
+		if assert.NoError(t, err, test.src) {
+			assert.Equal(t, test.expected, string(out), test.src)
+			again, err := Format(out)
+			if assert.NoError(t, err) {
+				assert.Equal(t, string(out), string(again), "formatting is not idempotent")
+			}
+		}
+	}
+
+	_, err := Format([]byte("{{#a}}"))
+	assert.Error(t, err)
+}
+
+func TestFormatExtensions(t *testing.T) {
+	src := "{{#items}}\n{{ name|upper |truncate   20|default \"n/a\" }}\n{{ else }}\nNone\n{{/items}}"
+	expected := "{{#items}}\n{{name | upper | truncate 20 | default n/a}}\n{{else}}\nNone\n{{/items}}"
+	out, err := Format([]byte(src), WithFilters(nil), WithElseDivider())
+	if assert.NoError(t, err) {
+		assert.Equal(t, expected, string(out))
+	}
+
+	src = "{{#a}}{{ ../b | default \"two words\" }}{{/a}}"
+	expected = "{{#a}}{{../b | default \"two words\"}}{{/a}}"
+	out, err = Format([]byte(src), WithFilters(nil), WithParentAccess())
+	if assert.NoError(t, err) {
+		assert.Equal(t, expected, string(out))
+	}
+}
@@ -0,0 +1,327 @@
+// Package lint implements static checks over mustache templates.
+package lint
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/cbroglie/go-mustache"
+)
+
+// File is a template to be checked.
+type File struct {
+	// Name is the template name, used to resolve partial tags.
+	Name string
+	// Path is the path reported in diagnostics.
+	Path string
+	// Contents is the template source.
+	Contents string
+}
+
+// Diagnostic is a problem found in a template.
+type Diagnostic struct {
+	Rule    string            `json:"rule"`
+	Path    string            `json:"path"`
+	Pos     mustache.Position `json:"pos"`
+	Message string            `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s (%s)", d.Path, d.Pos.Line, d.Pos.Column, d.Message, d.Rule)
+}
+
+// Rule describes a check.
+type Rule struct {
+	Name        string
+	Description string
+	// Default reports whether the rule is enabled unless configured otherwise.
+	Default bool
+
+	check func(l *linter, f *file)
+}
+
+// Rules lists every check, sorted by name.
+var Rules = []*Rule{
+	{
+		Name:        "deprecated-syntax",
+		Description: "Comments closed with !}}, a legacy form which is not part of the mustache spec.",
+		Default:     true,
+		check:       checkDeprecatedSyntax,
+	},
+	{
+		Name:        "missing-partial",
+		Description: "Partial tags naming a template which is not part of the checked set.",
+		Default:     true,
+		check:       checkMissingPartial,
+	},
+	{
+		Name:        "shadowed-section",
+		Description: "Sections nested inside a section of the same name, which hides the outer value.",
+		Default:     true,
+		check:       checkShadowedSection,
+	},
+	{
+		Name:        "syntax",
+		Description: "Templates which fail to parse.",
+		Default:     true,
+	},
+	{
+		Name:        "tag-spacing",
+		Description: "Tags whose inner padding ({{ name }} vs {{name}}) differs from the rest of the template.",
+		Default:     true,
+		check:       checkTagSpacing,
+	},
+	{
+		Name:        "unescaped-html",
+		Description: "Unescaped variables in HTML templates, which may allow cross-site scripting.",
+		Default:     true,
+		check:       checkUnescapedHTML,
+	},
+	{
+		Name:        "unused-partial",
+		Description: "Partial templates which no other template includes.",
+		Default:     true,
+	},
+}
+
+// Config configures a run of the linter. The zero value enables the default
+// rules.
+type Config struct {
+	// Rules enables or disables rules by name. Rules which are not listed
+	// use their default.
+	Rules map[string]bool
+	// Options are passed to the parser to enable syntax extensions.
+	Options []mustache.CompileOption
+	// IsPartial reports whether a template is meant to be used as a partial,
+	// for the unused-partial rule. By default, templates whose base name
+	// starts with an underscore are partials.
+	IsPartial func(name string) bool
+}
+
+func (c *Config) enabled(rule string) bool {
+	if enabled, ok := c.Rules[rule]; ok {
+		return enabled
+	}
+	for _, r := range Rules {
+		if r.Name == rule {
+			return r.Default
+		}
+	}
+	return false
+}
+
+func defaultIsPartial(name string) bool {
+	return strings.HasPrefix(path.Base(name), "_")
+}
+
+type file struct {
+	File
+	cst  *mustache.CST
+	html bool
+}
+
+type linter struct {
+	config      *Config
+	files       map[string]*file
+	diagnostics []Diagnostic
+}
+
+func (l *linter) report(rule string, f *file, pos mustache.Position, format string, args ...interface{}) {
+	l.diagnostics = append(l.diagnostics, Diagnostic{
+		Rule:    rule,
+		Path:    f.Path,
+		Pos:     pos,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+var htmlExt = map[string]bool{".html": true, ".htm": true}
+
+// Lint checks a set of templates and returns the problems found, sorted by
+// path and position. It returns an error if the configuration names an
+// unknown rule.
+func Lint(files []File, config *Config) ([]Diagnostic, error) {
+	if config == nil {
+		config = &Config{}
+	}
+	for name := range config.Rules {
+		if !known(name) {
+			return nil, fmt.Errorf("unknown rule %q", name)
+		}
+	}
+
+	l := &linter{config: config, files: make(map[string]*file, len(files))}
+	parsed := make([]*file, 0, len(files))
+	for _, f := range files {
+		lf := &file{
+			File: f,
+			html: htmlExt[strings.ToLower(path.Ext(f.Path))] || mustache.LooksLikeHTML(f.Contents),
+		}
+		l.files[f.Name] = lf
+		cst, err := mustache.ParseCST(f.Contents, config.Options...)
+		if err != nil {
+			if config.enabled("syntax") {
+				l.report("syntax", lf, mustache.Position{Line: 1, Column: 1}, "%v", err)
+			}
+			continue
+		}
+		lf.cst = cst
+		parsed = append(parsed, lf)
+	}
+
+	for _, f := range parsed {
+		for _, r := range Rules {
+			if r.check != nil && config.enabled(r.Name) {
+				r.check(l, f)
+			}
+		}
+	}
+	if config.enabled("unused-partial") {
+		l.checkUnusedPartials(parsed)
+	}
+
+	sort.SliceStable(l.diagnostics, func(i, j int) bool {
+		a, b := l.diagnostics[i], l.diagnostics[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Pos.Offset < b.Pos.Offset
+	})
+	return l.diagnostics, nil
+}
+
+func known(rule string) bool {
+	for _, r := range Rules {
+		if r.Name == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// enclosing is a section enclosing a node.
+type enclosing struct {
+	section *mustache.CSTNode
+	inElse  bool // the node follows the else divider of the section
+}
+
+// walk calls fn for every tag node of the tree, along with the sections
+// enclosing it. The tags of a section are enclosed by the section.
+func walk(nodes []*mustache.CSTNode, sections []enclosing, fn func(n *mustache.CSTNode, sections []enclosing)) {
+	for _, n := range nodes {
+		switch n.Kind {
+		case mustache.CSTText, mustache.CSTWhitespace:
+		case mustache.CSTSection:
+			inner := append(sections[:len(sections):len(sections)], enclosing{section: n})
+			for _, child := range n.Children {
+				if child.Kind == mustache.CSTElse {
+					inner[len(inner)-1].inElse = true
+				}
+				walk([]*mustache.CSTNode{child}, inner, fn)
+			}
+		default:
+			fn(n, sections)
+		}
+	}
+}
+
+func checkDeprecatedSyntax(l *linter, f *file) {
+	walk(f.cst.Nodes, nil, func(n *mustache.CSTNode, _ []enclosing) {
+		if n.Kind == mustache.CSTComment && len(n.Source) > len("{{!!}}") && strings.HasSuffix(n.Source, "!}}") {
+			l.report("deprecated-syntax", f, n.Start, "comment closed with !}}; use }}")
+		}
+	})
+}
+
+func checkMissingPartial(l *linter, f *file) {
+	walk(f.cst.Nodes, nil, func(n *mustache.CSTNode, _ []enclosing) {
+		if n.Kind == mustache.CSTPartial {
+			if _, ok := l.files[n.Token.Name()]; !ok {
+				l.report("missing-partial", f, n.Start, "partial %q not found", n.Token.Name())
+			}
+		}
+	})
+}
+
+func checkShadowedSection(l *linter, f *file) {
+	walk(f.cst.Nodes, nil, func(n *mustache.CSTNode, sections []enclosing) {
+		if n.Kind != mustache.CSTOpen || n.Token.Type() != mustache.Section || n.Token.Parents() > 0 || n.Token.Name() == "." {
+			return
+		}
+		// Inverted sections and else branches don't push a context, so only
+		// sections whose truthy branch encloses the tag shadow. The innermost
+		// section is the one being opened.
+		for _, e := range sections[:len(sections)-1] {
+			s := e.section
+			if !e.inElse && s.Token.Type() == mustache.Section && s.Token.Name() == n.Token.Name() {
+				l.report("shadowed-section", f, n.Start, "section %q shadows the enclosing section of the same name at %d:%d", n.Token.Name(), s.Start.Line, s.Start.Column)
+				return
+			}
+		}
+	})
+}
+
+// padded reports whether a tag has whitespace inside its delimiters.
+func padded(n *mustache.CSTNode) bool {
+	inner := strings.TrimPrefix(n.Source, "{{"+n.Sigil)
+	return inner != "" && (inner[0] == ' ' || inner[0] == '\t' || inner[0] == '\n')
+}
+
+func checkTagSpacing(l *linter, f *file) {
+	var tags []*mustache.CSTNode
+	count := 0
+	walk(f.cst.Nodes, nil, func(n *mustache.CSTNode, _ []enclosing) {
+		// Comments and else dividers are conventionally written differently.
+		if n.Kind == mustache.CSTComment || n.Kind == mustache.CSTElse {
+			return
+		}
+		tags = append(tags, n)
+		if padded(n) {
+			count++
+		}
+	})
+	// The majority style wins; ties favor unpadded tags.
+	majority := count*2 > len(tags)
+	for _, n := range tags {
+		if padded(n) != majority {
+			if majority {
+				l.report("tag-spacing", f, n.Start, "tag %s is not padded like the rest of the template", n.Source)
+			} else {
+				l.report("tag-spacing", f, n.Start, "tag %s is padded unlike the rest of the template", n.Source)
+			}
+		}
+	}
+}
+
+func checkUnescapedHTML(l *linter, f *file) {
+	if !f.html {
+		return
+	}
+	walk(f.cst.Nodes, nil, func(n *mustache.CSTNode, _ []enclosing) {
+		if n.Kind == mustache.CSTVariable && n.Token.Type() == mustache.UnescapedVariable {
+			l.report("unescaped-html", f, n.Start, "unescaped variable %q in HTML template", n.Token.Name())
+		}
+	})
+}
+
+func (l *linter) checkUnusedPartials(files []*file) {
+	isPartial := l.config.IsPartial
+	if isPartial == nil {
+		isPartial = defaultIsPartial
+	}
+	used := make(map[string]bool)
+	for _, f := range files {
+		walk(f.cst.Nodes, nil, func(n *mustache.CSTNode, _ []enclosing) {
+			if n.Kind == mustache.CSTPartial && n.Token.Name() != f.Name {
+				used[n.Token.Name()] = true
+			}
+		})
+	}
+	for _, f := range files {
+		if isPartial(f.Name) && !used[f.Name] {
+			l.report("unused-partial", f, mustache.Position{Line: 1, Column: 1}, "partial %q is not used by any template", f.Name)
+		}
+	}
+}
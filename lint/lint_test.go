@@ -0,0 +1,117 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/cbroglie/go-mustache"
+	"github.com/stretchr/testify/assert"
+)
+
+func lintOne(t *testing.T, path, contents string, config *Config) []string {
+	diagnostics, err := Lint([]File{{Name: "t", Path: path, Contents: contents}}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found []string
+	for _, d := range diagnostics {
+		found = append(found, d.String())
+	}
+	return found
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		path     string
+		contents string
+		expected []string
+	}{
+		{"t.mustache", "Hello {{name}}!", nil},
+		{"t.mustache", "{{{raw}}}", nil},
+		{"t.html", "{{{raw}}}", []string{`t.html:1:1: unescaped variable "raw" in HTML template (unescaped-html)`}},
+		{"t.mustache", "<p>{{{raw}}}</p>", []string{`t.mustache:1:4: unescaped variable "raw" in HTML template (unescaped-html)`}},
+		{"t.mustache", "{{! old !}}{{! new }}", []string{`t.mustache:1:1: comment closed with !}}; use }} (deprecated-syntax)`}},
+		{"t.mustache", "{{#a}}\n{{#b}}{{#a}}{{/a}}{{/b}}{{/a}}", []string{`t.mustache:2:7: section "a" shadows the enclosing section of the same name at 1:1 (shadowed-section)`}},
+		{"t.mustache", "{{#a}}{{^a}}{{/a}}{{/a}}{{^a}}{{#a}}{{/a}}{{/a}}", nil},
+		{"t.mustache", "{{a}} {{b}} {{ c }}", []string{`t.mustache:1:13: tag {{ c }} is padded unlike the rest of the template (tag-spacing)`}},
+		{"t.mustache", "{{ a }} {{ b }} {{c}}", []string{`t.mustache:1:17: tag {{c}} is not padded like the rest of the template (tag-spacing)`}},
+		{"t.mustache", "{{#a}}{{ b }}{{/a}}{{! comment }}", []string{`t.mustache:1:7: tag {{ b }} is padded unlike the rest of the template (tag-spacing)`}},
+		{"t.mustache", "{{>missing}}", []string{`t.mustache:1:1: partial "missing" not found (missing-partial)`}},
+		{"t.mustache", "{{a", []string{`t.mustache:1:1: Unclosed tag (syntax)`}},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, lintOne(t, test.path, test.contents, nil), test.contents)
+	}
+}
+
+func TestPartials(t *testing.T) {
+	files := []File{
+		{Name: "page", Path: "page.mustache", Contents: "{{>_header}}{{>_missing}}"},
+		{Name: "_header", Path: "_header.mustache", Contents: "{{>_header}}"},
+		{Name: "_footer", Path: "_footer.mustache", Contents: ""},
+	}
+	diagnostics, err := Lint(files, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []Diagnostic{
+		{Rule: "unused-partial", Path: "_footer.mustache", Pos: mustache.Position{Line: 1, Column: 1}, Message: `partial "_footer" is not used by any template`},
+		{Rule: "missing-partial", Path: "page.mustache", Pos: mustache.Position{Offset: 12, Line: 1, Column: 13}, Message: `partial "_missing" not found`},
+	}, diagnostics)
+
+	// A template which only includes itself is still unused.
+	diagnostics, err = Lint(files[1:2], &Config{IsPartial: func(string) bool { return true }})
+	assert.NoError(t, err)
+	assert.Len(t, diagnostics, 1)
+}
+
+func TestConfig(t *testing.T) {
+	contents := "{{a}} {{b}} {{ c }}{{>missing}}"
+	assert.Len(t, lintOne(t, "t.mustache", contents, nil), 2)
+	assert.Len(t, lintOne(t, "t.mustache", contents, &Config{Rules: map[string]bool{"tag-spacing": false}}), 1)
+	assert.Len(t, lintOne(t, "t.mustache", contents, &Config{Rules: map[string]bool{"tag-spacing": false, "missing-partial": false}}), 0)
+
+	// Syntax extensions are enabled through the parser options.
+	assert.Len(t, lintOne(t, "t.mustache", "{{name | upper}}", nil), 1)
+	assert.Len(t, lintOne(t, "t.mustache", "{{name | upper}}", &Config{Options: []mustache.CompileOption{mustache.WithFilters(nil)}}), 0)
+
+	// A section in the else branch of a section of the same name is not
+	// shadowed, since the outer context is not pushed there.
+	elseOpts := &Config{Options: []mustache.CompileOption{mustache.WithElseDivider()}}
+	assert.Len(t, lintOne(t, "t.mustache", "{{#a}}{{^}}{{#a}}{{/a}}{{/a}}", elseOpts), 0)
+	assert.Len(t, lintOne(t, "t.mustache", "{{#a}}{{#a}}{{/a}}{{else}}{{#a}}{{/a}}{{/a}}", elseOpts), 1)
+
+	_, err := Lint(nil, &Config{Rules: map[string]bool{"no-such-rule": true}})
+	assert.EqualError(t, err, `unknown rule "no-such-rule"`)
+}
+
+func TestOutput(t *testing.T) {
+	diagnostics := []Diagnostic{
+		{Rule: "missing-partial", Path: "a.mustache", Pos: mustache.Position{Offset: 4, Line: 2, Column: 1}, Message: `partial "b" not found`},
+	}
+
+	var b bytes.Buffer
+	assert.NoError(t, WriteText(&b, diagnostics))
+	assert.Equal(t, "a.mustache:2:1: partial \"b\" not found (missing-partial)\n", b.String())
+
+	b.Reset()
+	assert.NoError(t, WriteJSON(&b, nil))
+	assert.Equal(t, "[]\n", b.String())
+	b.Reset()
+	assert.NoError(t, WriteJSON(&b, diagnostics))
+	var decoded []Diagnostic
+	assert.NoError(t, json.Unmarshal(b.Bytes(), &decoded))
+	assert.Equal(t, diagnostics, decoded)
+
+	b.Reset()
+	assert.NoError(t, WriteSARIF(&b, diagnostics))
+	var log sarifLog
+	assert.NoError(t, json.Unmarshal(b.Bytes(), &log))
+	assert.Equal(t, "2.1.0", log.Version)
+	assert.Len(t, log.Runs, 1)
+	assert.Len(t, log.Runs[0].Tool.Driver.Rules, len(Rules))
+	result := log.Runs[0].Results[0]
+	assert.Equal(t, "missing-partial", result.RuleID)
+	assert.Equal(t, "missing-partial", log.Runs[0].Tool.Driver.Rules[result.RuleIndex].ID)
+	assert.Equal(t, "warning", result.Level)
+	assert.Equal(t, sarifRegion{StartLine: 2, StartColumn: 1}, result.Locations[0].PhysicalLocation.Region)
+}
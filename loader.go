@@ -0,0 +1,183 @@
+package mustache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Loader compiles the templates in a directory and recompiles them when they
+// change, which is useful during development. Changes are found by polling
+// file modification times and sizes. Templates are named by their path
+// relative to the directory, using forward slashes and without the extension,
+// so that they can refer to each other as partials (e.g. `{{> users/row}}`).
+//
+// Reloading swaps in a new set of templates atomically. If a changed file
+// fails to compile, the last good version of that template is kept. Its
+// methods are safe for concurrent use.
+type Loader struct {
+	dir  string
+	ext  string
+	opts []CompileOption
+
+	templates atomic.Value // map[string]*Template
+
+	mu    sync.Mutex // serializes reloads
+	files map[string]fileInfo
+}
+
+type fileInfo struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewLoader compiles every file with the extension ext (e.g. ".mustache")
+// under dir. Unlike later reloads, any compile error fails the initial load.
+func NewLoader(dir, ext string, opts ...CompileOption) (*Loader, error) {
+	l := &Loader{
+		dir:   dir,
+		ext:   ext,
+		opts:  opts,
+		files: make(map[string]fileInfo),
+	}
+	l.templates.Store(map[string]*Template{})
+	if err := l.Reload(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Lookup returns the current version of the named template.
+func (l *Loader) Lookup(name string) (*Template, bool) {
+	t, ok := l.templates.Load().(map[string]*Template)[name]
+	return t, ok
+}
+
+// Names returns the sorted names of the loaded templates.
+func (l *Loader) Names() []string {
+	templates := l.templates.Load().(map[string]*Template)
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Reload checks the directory for added, changed and removed files, and swaps
+// in the resulting set of templates. Templates which fail to compile keep
+// their last good version and the first such error is returned. If the new
+// set contains a partial cycle (see CompileSet) the whole set is left
+// unchanged.
+func (l *Loader) Reload() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	files, err := l.scan()
+	if err != nil {
+		return err
+	}
+
+	old := l.templates.Load().(map[string]*Template)
+	templates := make(map[string]*Template, len(files))
+	changed := len(files) != len(l.files)
+	var firstErr error
+	for name, info := range files {
+		if prev, ok := l.files[name]; ok && prev.modTime.Equal(info.modTime) && prev.size == info.size {
+			if t, ok := old[name]; ok {
+				templates[name] = t
+			}
+			continue
+		}
+		changed = true
+		t, err := l.compile(name)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			if t, ok := old[name]; ok {
+				templates[name] = t
+			}
+			continue
+		}
+		templates[name] = t
+	}
+	if !changed {
+		return nil
+	}
+
+	// The file states are only recorded once the new set is accepted, so a
+	// rejected set is compiled again on the next reload.
+	if err := checkPartialCycles(templates); err != nil {
+		return err
+	}
+	l.files = files
+	l.templates.Store(templates)
+	return firstErr
+}
+
+// Watch calls Reload every interval until the returned stop function is
+// called. Reload errors are passed to onError, if it is not nil.
+func (l *Loader) Watch(interval time.Duration, onError func(error)) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				if err := l.Reload(); err != nil && onError != nil {
+					onError(err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ticker.Stop()
+			close(done)
+		})
+	}
+}
+
+// scan returns the state of every template file under the directory, keyed
+// by template name.
+func (l *Loader) scan() (map[string]fileInfo, error) {
+	files := make(map[string]fileInfo)
+	err := filepath.Walk(l.dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Ext(path) != l.ext {
+			return nil
+		}
+		rel, err := filepath.Rel(l.dir, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(strings.TrimSuffix(rel, l.ext))
+		files[name] = fileInfo{modTime: info.ModTime(), size: info.Size()}
+		return nil
+	})
+	return files, err
+}
+
+func (l *Loader) compile(name string) (*Template, error) {
+	path := filepath.Join(l.dir, filepath.FromSlash(name)+l.ext)
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	t, err := Compile(string(contents), l.opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return t, nil
+}
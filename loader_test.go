@@ -0,0 +1,123 @@
+package mustache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTemplate(t *testing.T, dir, name, contents string, modTime time.Time) {
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoader(t *testing.T) {
+	dir := t.TempDir()
+	modTime := time.Now().Add(-time.Hour)
+	writeTemplate(t, dir, "page.mustache", "{{>users/row}}", modTime)
+	writeTemplate(t, dir, "users/row.mustache", "{{name}}", modTime)
+	writeTemplate(t, dir, "README.md", "{{#unclosed}}", modTime)
+
+	l, err := NewLoader(dir, ".mustache")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"page", "users/row"}, l.Names())
+	page, ok := l.Lookup("page")
+	if assert.True(t, ok) {
+		assert.Equal(t, "users/row", page.Tokens()[0].Name())
+	}
+
+	// Nothing changed.
+	assert.NoError(t, l.Reload())
+	page2, _ := l.Lookup("page")
+	assert.True(t, page == page2, "expected the same template")
+
+	// A changed file is recompiled, and removed files are dropped.
+	modTime = modTime.Add(time.Minute)
+	writeTemplate(t, dir, "page.mustache", "{{title}}", modTime)
+	assert.NoError(t, os.Remove(filepath.Join(dir, "users", "row.mustache")))
+	assert.NoError(t, l.Reload())
+	assert.Equal(t, []string{"page"}, l.Names())
+	page, _ = l.Lookup("page")
+	assert.Equal(t, "title", page.Tokens()[0].Name())
+
+	// A file which fails to compile keeps its last good version.
+	modTime = modTime.Add(time.Minute)
+	writeTemplate(t, dir, "page.mustache", "{{#title}}", modTime)
+	writeTemplate(t, dir, "footer.mustache", "{{year}}", modTime)
+	assert.Error(t, l.Reload())
+	page2, _ = l.Lookup("page")
+	assert.True(t, page == page2, "expected the last good template")
+	_, ok = l.Lookup("footer")
+	assert.True(t, ok)
+
+	// A partial cycle leaves the whole set unchanged.
+	modTime = modTime.Add(time.Minute)
+	writeTemplate(t, dir, "page.mustache", "{{>footer}}", modTime)
+	writeTemplate(t, dir, "footer.mustache", "{{>page}}", modTime)
+	assert.Error(t, l.Reload())
+	page2, _ = l.Lookup("page")
+	assert.True(t, page == page2, "expected the last good template")
+	assert.Error(t, l.Reload())
+}
+
+func TestLoaderErrors(t *testing.T) {
+	_, err := NewLoader(filepath.Join(t.TempDir(), "missing"), ".mustache")
+	assert.Error(t, err)
+
+	dir := t.TempDir()
+	writeTemplate(t, dir, "page.mustache", "{{/page}}", time.Now())
+	_, err = NewLoader(dir, ".mustache")
+	assert.Error(t, err)
+}
+
+func TestLoaderWatch(t *testing.T) {
+	dir := t.TempDir()
+	modTime := time.Now().Add(-time.Hour)
+	writeTemplate(t, dir, "page.mustache", "{{a}}", modTime)
+	l, err := NewLoader(dir, ".mustache")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	errs := make(chan error, 1)
+	stop := l.Watch(time.Millisecond, func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	})
+	defer stop()
+
+	writeTemplate(t, dir, "page.mustache", "{{b}}", modTime.Add(time.Minute))
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if page, _ := l.Lookup("page"); page.Tokens()[0].Name() == "b" {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	page, _ := l.Lookup("page")
+	assert.Equal(t, "b", page.Tokens()[0].Name())
+
+	writeTemplate(t, dir, "page.mustache", "{{/b}}", modTime.Add(2*time.Minute))
+	select {
+	case err := <-errs:
+		assert.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Error("expected a reload error")
+	}
+	stop()
+}
@@ -3,6 +3,8 @@ package mustache
 import (
 	"fmt"
 	"regexp"
+	"strconv"
+	"strings"
 )
 
 // TokenType represents the different tokens the parser extracts from a string
@@ -21,83 +23,200 @@ const (
 	closeSection // not exported as section close tags are not part of the final parse tree
 )
 
+var tokenTypeNames = map[TokenType]string{
+	Text:              "Text",
+	Section:           "Section",
+	InvertedSection:   "InvertedSection",
+	Variable:          "Variable",
+	UnescapedVariable: "UnescapedVariable",
+	Partial:           "Partial",
+}
+
+func (t TokenType) String() string {
+	if name, ok := tokenTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("TokenType(%d)", uint(t))
+}
+
+// Position describes a location in the template source.
+type Position struct {
+	Offset int `json:"offset"` // byte offset, starting at 0
+	Line   int `json:"line"`   // line number, starting at 1
+	Column int `json:"column"` // byte offset within the line, starting at 1
+}
+
 // Token represents a mustache token.
 //
 // Not all methods apply to all kinds of tokens. Restrictions, if any, are noted
 // in the documentation for each method. Use the Type method to find out the
 // type of token before calling type-specific methods. Calling a method
-// inappropriate to the type of token causes a run time panic.
+// inappropriate to the type of token causes a run time panic. The TokenName,
+// TokenChildren and related functions do not panic, and Walk and Inspect
+// traverse a token tree.
 type Token interface {
 	// Type returns the type of the token.
 	Type() TokenType
+	// Pos returns the position of the start of the token in the template
+	// source. For tags, this is the position of the opening delimiter.
+	Pos() Position
 	// Name returns the name of the token. It panics for token types which are
 	// not named (i.e. text tokens).
 	Name() string
 	// Tokens returns any child tokens. It panics for token types which cannot
 	// contain child tokens (i.e. text and variable tokens).
 	Tokens() []Token
+	// Parents returns the number of `../` segments which prefixed the name,
+	// i.e. how many levels of the context stack are skipped when resolving it.
+	// It panics for token types which are not named (i.e. text tokens).
+	Parents() int
+	// ElseTokens returns the child tokens following an else divider, which
+	// are used when a section is falsy. It returns nil if the section has no
+	// divider, and panics for token types which cannot contain child tokens.
+	ElseTokens() []Token
+	// Filters returns the filter chain applied to a variable, in the order the
+	// filters are applied. It panics for token types other than variables.
+	Filters() []FilterCall
+}
+
+// source records where a token appeared in the template source.
+type source struct {
+	start      Position
+	end        Position
+	raw        string // the text of the tag, empty for text tokens
+	standalone bool   // the tag was alone on its line, which was removed
 }
 
 type text struct {
+	source
 	value string
 }
 
 type section struct {
+	source
+	close    source // the closing tag
 	name     string
+	parents  int
 	inverted bool
 	tokens   []Token
+
+	// While the falsy branch is being parsed, elseTokens holds the truthy
+	// branch so that new tokens can be appended to tokens as usual. The two
+	// are swapped back when the section is closed.
+	elseTokens []Token
 }
 
 type variable struct {
-	name   string
-	escape bool
+	source
+	name    string
+	parents int
+	escape  bool
+	filters []FilterCall
 }
 
 type partial struct {
+	source
 	name  string
 	value *section
 }
 
-// Template represents a compiled mustache Template. Its methods are safe for
-// concurrent use.
+// Template represents a compiled mustache Template. A Template is not modified
+// after Compile returns, so its methods are safe for concurrent use.
 type Template struct {
+	tokens []Token
+	funcs  FuncMap // filters referenced by variable tokens
+}
+
+// parser holds the state used while compiling a Template.
+type parser struct {
 	result   *section
 	sections []*section
 	scanner  *stringScanner
 	error    error
+	tag      *source // source of the tag being parsed, if it adds to the tree
+	token    Token   // token added or closed by the tag being parsed
+	cst      *cstBuilder
+
+	// Options set by CompileOption.
+	funcs        FuncMap
+	arities      map[string]arity // argument counts of the builtin filters in funcs
+	parentAccess bool
+	elseDivider  bool
 }
 
 var (
 	openTag        = regexp.MustCompile(`([ \t]*)?(\{\{)`)
 	notOpenTag     = regexp.MustCompile(`(?m)(^[ \t]*)?(\{\{)`)
-	tagType        = regexp.MustCompile(`(\!|\{|#|\/|\^)`)
+	tagType        = regexp.MustCompile(`(\!|\{|#|\/|\^|>)`)
 	allowedContent = regexp.MustCompile(`(\w|[?!\/.-])*`)
+	parentPath     = regexp.MustCompile(`^((?:\.\./)*)(.*)$`)
+	filterPipe     = regexp.MustCompile(`\s*\|\s*`)
+	filterName     = regexp.MustCompile(`\w+`)
+	filterArg      = regexp.MustCompile(`[ \t]+("(?:[^"\\]|\\.)*"|[^\s|}"]+)`)
 	closeTag       = map[TokenType]*regexp.Regexp{
 		Variable:          regexp.MustCompile(`([ \t]*)?(\}\})`),
 		UnescapedVariable: regexp.MustCompile(`([ \t]*)?(\}\}\})`),
 		comment:           regexp.MustCompile(`([ \t]*)?(\!?\}\})`),
 		InvertedSection:   regexp.MustCompile(`([ \t]*)?(\}\})`),
 		Section:           regexp.MustCompile(`([ \t]*)?(\}\})`),
+		Partial:           regexp.MustCompile(`([ \t]*)?(\}\})`),
 		closeSection:      regexp.MustCompile(`([ \t]*)?(\}\})`),
 	}
 )
 
+// CompileOption configures optional parser behavior for Compile.
+type CompileOption func(*parser)
+
+// WithParentAccess enables `../` path segments in variable and section names.
+// Each leading `../` skips one level of the context stack, so `{{../name}}`
+// resolves name in the context enclosing the innermost section. Walking past
+// the root context of the template is a compile error.
+func WithParentAccess() CompileOption {
+	return func(p *parser) {
+		p.parentAccess = true
+	}
+}
+
+// WithElseDivider enables `{{^}}` and `{{else}}` as dividers inside a section,
+// e.g. `{{#items}}...{{^}}None{{/items}}`. Tokens after the divider are
+// returned by ElseTokens and are used when the section is falsy.
+func WithElseDivider() CompileOption {
+	return func(p *parser) {
+		p.elseDivider = true
+	}
+}
+
 // Compile takes a string mustache Template and compiles it so that it can be
-// rendered.
-func Compile(contents string) (*Template, error) {
-	t := &Template{}
-	if err := t.parse(contents); err != nil {
+// rendered. Options may be given to enable syntax extensions.
+func Compile(contents string, opts ...CompileOption) (*Template, error) {
+	p := &parser{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if err := p.parse(contents); err != nil {
 		return nil, err
 	}
-	return t, nil
+	return &Template{tokens: p.result.tokens, funcs: p.funcs}, nil
 }
 
 func (t *Template) Render(context interface{}) (string, error) {
 	return "", nil
 }
 
+// Tokens returns the top-level tokens of the template. The slice is a copy, as
+// are those returned by the Tokens, ElseTokens and Filters methods of the
+// tokens, so modifying it does not affect the template.
 func (t *Template) Tokens() []Token {
-	return t.result.tokens
+	return copyTokens(t.tokens)
+}
+
+func copyTokens(tokens []Token) []Token {
+	if tokens == nil {
+		return nil
+	}
+	c := make([]Token, len(tokens))
+	copy(c, tokens)
+	return c
 }
 
 func newSection() *section {
@@ -106,34 +225,37 @@ func newSection() *section {
 	}
 }
 
-func (t *Template) parse(contents string) error {
-	t.scanner = &stringScanner{input: contents}
-	t.result = newSection()
-	t.sections = make([]*section, 0)
+func (p *parser) parse(contents string) error {
+	p.scanner = &stringScanner{input: contents}
+	p.result = newSection()
+	p.sections = make([]*section, 0)
+	if p.cst != nil {
+		p.cst.scanner = p.scanner
+	}
 
-	for t.error == nil && !t.scanner.Done() {
-		if t.parseTags() {
+	for p.error == nil && !p.scanner.Done() {
+		if p.parseTags() {
 			continue
 		}
-		t.parseText()
+		p.parseText()
 	}
-	if t.error != nil {
-		return t.error
+	if p.error != nil {
+		return p.error
 	}
 
 	// We have parsed the whole Template, but there are still open sections.
-	if len(t.sections) != 0 {
-		return fmt.Errorf("Unclosed section %q", t.sections[0].name)
+	if len(p.sections) != 0 {
+		return fmt.Errorf("Unclosed section %q", p.sections[0].name)
 	}
 
 	return nil
 }
 
-func (t *Template) parseTokenType() (TokenType, error) {
+func (p *parser) parseTokenType() (TokenType, error) {
 	// Parse the next character after the opening tag "{{"
 	// If it's not one of the special control characters assume it's a regular
 	// variable tag.
-	matches := t.scanner.Scan(tagType)
+	matches := p.scanner.Scan(tagType)
 	if len(matches) == 0 {
 		return Variable, nil
 	}
@@ -148,33 +270,135 @@ func (t *Template) parseTokenType() (TokenType, error) {
 		return InvertedSection, nil
 	case "/":
 		return closeSection, nil
+	case ">":
+		return Partial, nil
 	default:
 		return 0, fmt.Errorf("Unexpected tag type %s", matches[0])
 	}
 }
 
-func (t *Template) parseContent(tokenType TokenType) (string, error) {
+func (p *parser) parseContent(tokenType TokenType) (string, error) {
 	if tokenType == comment {
-		matches := t.scanner.ScanUntil(closeTag[tokenType])
+		matches := p.scanner.ScanUntil(closeTag[tokenType])
 		// Backup the scan pointer to just before the match.
 		if len(matches) > 0 {
-			t.scanner.SetPos(t.scanner.Pos() - len(matches[1]))
+			p.scanner.SetPos(p.scanner.Pos() - len(matches[1]))
 			return matches[0], nil
 		}
 		return "", nil
 	}
 
-	matches := t.scanner.Scan(allowedContent)
+	matches := p.scanner.Scan(allowedContent)
 	if len(matches) == 0 {
 		return "", fmt.Errorf("Illegal content in tag")
 	}
 	return matches[0], nil
 }
 
-func (t *Template) addTokens(tokenType TokenType, content string) error {
+func (p *parser) parseFilters() ([]FilterCall, error) {
+	var filters []FilterCall
+	for p.scanner.Scan(filterPipe) != nil {
+		matches := p.scanner.Scan(filterName)
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("Missing filter name")
+		}
+		call := FilterCall{Name: matches[0]}
+		for {
+			matches = p.scanner.Scan(filterArg)
+			if len(matches) == 0 {
+				break
+			}
+			arg := matches[1]
+			if strings.HasPrefix(arg, `"`) {
+				var err error
+				if arg, err = strconv.Unquote(arg); err != nil {
+					return nil, fmt.Errorf("Illegal filter argument %s", matches[1])
+				}
+			}
+			call.Args = append(call.Args, arg)
+		}
+		if err := p.checkFilter(call); err != nil {
+			return nil, err
+		}
+		filters = append(filters, call)
+	}
+	return filters, nil
+}
+
+// parsePath splits the leading `../` segments off of a name when parent
+// access is enabled.
+func (p *parser) parsePath(tokenType TokenType, content string) (string, int, error) {
+	if !p.parentAccess || tokenType == comment || tokenType == Partial {
+		return content, 0, nil
+	}
+	matches := parentPath.FindStringSubmatch(content)
+	name := matches[2]
+	parents := len(matches[1]) / len("../")
+	for _, segment := range strings.Split(name, "/") {
+		if segment == ".." {
+			return "", 0, fmt.Errorf("Illegal parent access in %q", content)
+		}
+	}
+	if parents == 0 {
+		return content, 0, nil
+	}
+	if name == "" {
+		return "", 0, fmt.Errorf("Missing name after parent access in %q", content)
+	}
+	if tokenType != closeSection && parents > p.depth() {
+		return "", 0, fmt.Errorf("Parent access in %q walks past the root context", content)
+	}
+	return name, parents, nil
+}
+
+// depth returns the number of contexts pushed by the sections enclosing the
+// current position. Inverted sections do not push a context, and neither does
+// the falsy branch of a section, which is being parsed once elseTokens is set.
+func (p *parser) depth() int {
+	if len(p.sections) == 0 {
+		return 0
+	}
+	n := 0
+	for _, s := range append(p.sections[1:len(p.sections):len(p.sections)], p.result) {
+		if !s.inverted && s.elseTokens == nil {
+			n++
+		}
+	}
+	return n
+}
+
+func isElseDivider(tokenType TokenType, content string) bool {
+	return (tokenType == InvertedSection && content == "") || (tokenType == Variable && content == "else")
+}
+
+func (p *parser) addTokens(tokenType TokenType, content string, filters []FilterCall) error {
+	p.tag, p.token = nil, nil
+	if p.elseDivider && isElseDivider(tokenType, content) {
+		if len(p.sections) == 0 {
+			return fmt.Errorf("Else divider outside of a section")
+		}
+		if p.result.inverted {
+			return fmt.Errorf("Else divider in inverted section %s", p.result.name)
+		}
+		if len(filters) > 0 {
+			return fmt.Errorf("Filters on else divider in section %s", p.result.name)
+		}
+		if p.result.elseTokens != nil {
+			return fmt.Errorf("Multiple else dividers in section %s", p.result.name)
+		}
+		p.result.elseTokens = p.result.tokens
+		p.result.tokens = make([]Token, 0)
+		return nil
+	}
+	content, parents, err := p.parsePath(tokenType, content)
+	if err != nil {
+		return err
+	}
 	switch tokenType {
 	case Variable, UnescapedVariable:
-		t.result.tokens = append(t.result.tokens, &variable{name: content, escape: tokenType == Variable})
+		v := &variable{name: content, parents: parents, escape: tokenType == Variable, filters: filters}
+		p.result.tokens = append(p.result.tokens, v)
+		p.tag, p.token = &v.source, v
 	case Section, InvertedSection:
 		// Sections work using a stack: each new section pushes the current
 		// result onto the stack, and new tags are added to the new section.
@@ -182,88 +406,138 @@ func (t *Template) addTokens(tokenType TokenType, content string) error {
 		// back off the stack.
 		s := newSection()
 		s.name = content
+		s.parents = parents
 		s.inverted = tokenType == InvertedSection
-		t.result.tokens = append(t.result.tokens, s)
-		t.sections = append(t.sections, t.result)
-		t.result = s
+		p.result.tokens = append(p.result.tokens, s)
+		p.sections = append(p.sections, p.result)
+		p.result = s
+		p.tag, p.token = &s.source, s
+	case Partial:
+		if content == "" {
+			return fmt.Errorf("Missing partial name")
+		}
+		partial := &partial{name: content}
+		p.result.tokens = append(p.result.tokens, partial)
+		p.tag, p.token = &partial.source, partial
 	case closeSection:
-		if len(t.sections) == 0 {
+		if len(p.sections) == 0 {
 			return fmt.Errorf("Closing unopened section %s", content)
 		}
-		if t.result.name != content {
-			return fmt.Errorf("Unclosed section %s", t.result.name)
+		if p.result.name != content || p.result.parents != parents {
+			return fmt.Errorf("Unclosed section %s", p.result.name)
+		}
+		if p.result.elseTokens != nil {
+			p.result.tokens, p.result.elseTokens = p.result.elseTokens, p.result.tokens
 		}
-		n := len(t.sections)
-		s := t.sections[n-1]
-		t.result = s
-		t.sections = t.sections[0 : n-1]
+		p.tag, p.token = &p.result.close, p.result
+		n := len(p.sections)
+		s := p.sections[n-1]
+		p.result = s
+		p.sections = p.sections[0 : n-1]
 	}
 	return nil
 }
 
-func (t *Template) parseTags() bool {
-	startOfLine := t.scanner.StartOfLine()
+func (p *parser) parseTags() bool {
+	startOfLine := p.scanner.StartOfLine()
 
 	// Look for an opening tag.
-	matches := t.scanner.Scan(openTag)
+	matches := p.scanner.Scan(openTag)
 	if len(matches) == 0 {
 		return false
 	}
 	if len(matches) != 3 {
-		t.error = fmt.Errorf("Unexpected regex match %v", matches)
+		p.error = fmt.Errorf("Unexpected regex match %v", matches)
 		return true
 	}
 
 	// If we're matching the start of a new line we hold off on adding the
 	// whitespace; it may be skipped based on the type of tag we've matched.
 	padding := matches[1]
+	start := p.scanner.Pos() - len(matches[2])
 	if !startOfLine && len(padding) > 0 {
-		t.result.tokens = append(t.result.tokens, &text{value: padding})
+		p.result.tokens = append(p.result.tokens, p.newText(padding, start-len(padding)))
 	}
 
 	// Scan ahead to figure out which kind of token this is.
-	tokenType, err := t.parseTokenType()
+	tokenType, err := p.parseTokenType()
 	if err != nil {
-		t.error = err
+		p.error = err
 		return true
 	}
 
 	// Skip over any whitespace between the opening tag and the content.
-	t.scanner.Scan(regexp.MustCompile(`\s*`))
+	p.scanner.Scan(regexp.MustCompile(`\s*`))
+	contentStart := p.scanner.Pos()
 
 	// Parse the content in the tag. The rules vary by type.
-	content, err := t.parseContent(tokenType)
+	content, err := p.parseContent(tokenType)
 	if err != nil {
-		t.error = err
+		p.error = err
 		return true
 	}
 
+	// Parse the filter chain, if the filter syntax is enabled.
+	var filters []FilterCall
+	if p.funcs != nil && (tokenType == Variable || tokenType == UnescapedVariable) {
+		filters, err = p.parseFilters()
+		if err != nil {
+			p.error = err
+			return true
+		}
+	}
+
 	// Add the token to the parse tree.
-	err = t.addTokens(tokenType, content)
+	err = p.addTokens(tokenType, content, filters)
 	if err != nil {
-		t.error = err
+		p.error = err
 		return true
 	}
 
 	// Skip over any whitespace between the content and the closing tag.
-	t.scanner.Scan(regexp.MustCompile(`\s*`))
+	contentEnd := p.scanner.Pos()
+	p.scanner.Scan(regexp.MustCompile(`\s*`))
 
 	// Find the closing tag.
-	matches = t.scanner.Scan(closeTag[tokenType])
+	matches = p.scanner.Scan(closeTag[tokenType])
 	if len(matches) == 0 {
-		t.error = fmt.Errorf("Unclosed tag")
+		p.error = fmt.Errorf("Unclosed tag")
 		return true
 	}
 
+	// Record where the tag appeared.
+	end := p.scanner.Pos()
+	if p.tag != nil {
+		p.tag.start = p.scanner.Position(start)
+		p.tag.end = p.scanner.Position(end)
+		p.tag.raw, _ = p.scanner.Substring(start, end)
+	}
+
 	// If this tag was the only non-whitespace content on this line, strip the
 	// remaining whitespace. If not, but we've been hanging on to padding from
 	// the beginning of the line, re-insert the padding as static text.
-	if startOfLine && !t.scanner.Done() {
-		if skipWhitespace(tokenType) && t.scanner.Check(regexp.MustCompile(`[\t ]*\r?\n`)) != nil {
-			t.scanner.Scan(regexp.MustCompile(`[\t ]*\r?\n`))
+	standalone := false
+	if startOfLine && !p.scanner.Done() {
+		if skipWhitespace(tokenType) && p.scanner.Check(regexp.MustCompile(`[\t ]*\r?\n`)) != nil {
+			p.scanner.Scan(regexp.MustCompile(`[\t ]*\r?\n`))
+			standalone = true
+			if p.tag != nil {
+				p.tag.standalone = true
+			}
 		} else if len(padding) > 0 {
-			t.result.tokens = append(t.result.tokens, &text{value: padding})
+			p.result.tokens = append(p.result.tokens, p.newText(padding, start-len(padding)))
+		}
+	}
+
+	if p.cst != nil {
+		kind := cstKinds[tokenType]
+		if p.elseDivider && isElseDivider(tokenType, content) {
+			kind = CSTElse
 		}
+		// Padding at the start of the line is dropped if the tag is standalone,
+		// or if the tag ends the template.
+		dropped := startOfLine && (standalone || p.scanner.Done())
+		p.cst.tag(kind, p.token, start-len(padding), start, contentStart, contentEnd, end, p.scanner.Pos(), dropped)
 	}
 
 	return false
@@ -280,44 +554,60 @@ func skipWhitespace(tokenType TokenType) bool {
 	return true
 }
 
-func (t *Template) parseText() bool {
-	if t.scanner.Done() {
+// newText returns a text token for value, which starts at offset in the
+// template source.
+func (p *parser) newText(value string, offset int) *text {
+	return &text{
+		source: source{
+			start: p.scanner.Position(offset),
+			end:   p.scanner.Position(offset + len(value)),
+		},
+		value: value,
+	}
+}
+
+func (p *parser) parseText() bool {
+	if p.scanner.Done() {
 		return false
 	}
 
 	// Scan up to the next open tag.
-	matches := t.scanner.ScanUntil(notOpenTag)
+	matches := p.scanner.ScanUntil(notOpenTag)
 
 	// No more open tags, add the remaining string as text.
 	if len(matches) == 0 {
-		rest, err := t.scanner.Substring(t.scanner.Pos(), t.scanner.Len())
+		rest, err := p.scanner.Substring(p.scanner.Pos(), p.scanner.Len())
 		if err != nil {
-			t.error = err
+			p.error = err
 		} else {
-			t.result.tokens = append(t.result.tokens, &text{value: rest})
-			t.scanner.SetPos(t.scanner.Len())
+			p.result.tokens = append(p.result.tokens, p.newText(rest, p.scanner.Pos()))
+			p.scanner.SetPos(p.scanner.Len())
 		}
 		return true
 	}
 
 	// Sanity check the regex.
 	if len(matches) != 4 {
-		t.error = fmt.Errorf("Unexpected regex match %v", matches)
+		p.error = fmt.Errorf("Unexpected regex match %v", matches)
 		return true
 	}
 
 	// Backup the scan pointer to just before the match.
-	t.scanner.SetPos(t.scanner.Pos() - len(matches[1]))
+	p.scanner.SetPos(p.scanner.Pos() - len(matches[1]))
 
 	// Add the text up to the match.
 	txt := matches[0][0 : len(matches[0])-len(matches[1])]
 	if len(txt) > 0 {
-		t.result.tokens = append(t.result.tokens, &text{value: txt})
+		p.result.tokens = append(p.result.tokens, p.newText(txt, p.scanner.Pos()-len(txt)))
 	}
 
 	return true
 }
 
+func (s *source) Pos() Position {
+	return s.start
+}
+
 func (v *variable) Type() TokenType {
 	if v.escape {
 		return Variable
@@ -336,6 +626,28 @@ func (v *variable) Tokens() []Token {
 	panic("mustache: Tokens on UnescapedVariable type")
 }
 
+func (v *variable) Parents() int {
+	return v.parents
+}
+
+func (v *variable) ElseTokens() []Token {
+	if v.escape {
+		panic("mustache: ElseTokens on Variable type")
+	}
+	panic("mustache: ElseTokens on UnescapedVariable type")
+}
+
+func (v *variable) Filters() []FilterCall {
+	if v.filters == nil {
+		return nil
+	}
+	filters := make([]FilterCall, len(v.filters))
+	for i, f := range v.filters {
+		filters[i] = FilterCall{Name: f.Name, Args: append([]string(nil), f.Args...)}
+	}
+	return filters
+}
+
 func (t *text) Type() TokenType {
 	return Text
 }
@@ -348,6 +660,18 @@ func (t *text) Tokens() []Token {
 	panic("mustache: Tokens on Text type")
 }
 
+func (t *text) Parents() int {
+	panic("mustache: Parents on Text type")
+}
+
+func (t *text) ElseTokens() []Token {
+	panic("mustache: ElseTokens on Text type")
+}
+
+func (t *text) Filters() []FilterCall {
+	panic("mustache: Filters on Text type")
+}
+
 func (s *section) Type() TokenType {
 	if s.inverted {
 		return InvertedSection
@@ -360,5 +684,44 @@ func (s *section) Name() string {
 }
 
 func (s *section) Tokens() []Token {
-	return s.tokens
+	return copyTokens(s.tokens)
+}
+
+func (s *section) Parents() int {
+	return s.parents
+}
+
+func (s *section) ElseTokens() []Token {
+	return copyTokens(s.elseTokens)
+}
+
+func (s *section) Filters() []FilterCall {
+	if s.inverted {
+		panic("mustache: Filters on InvertedSection type")
+	}
+	panic("mustache: Filters on Section type")
+}
+
+func (p *partial) Type() TokenType {
+	return Partial
+}
+
+func (p *partial) Name() string {
+	return p.name
+}
+
+func (p *partial) Tokens() []Token {
+	panic("mustache: Tokens on Partial type")
+}
+
+func (p *partial) Parents() int {
+	return 0
+}
+
+func (p *partial) ElseTokens() []Token {
+	panic("mustache: ElseTokens on Partial type")
+}
+
+func (p *partial) Filters() []FilterCall {
+	panic("mustache: Filters on Partial type")
 }
@@ -2,6 +2,7 @@ package mustache
 
 import (
 	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -12,17 +13,38 @@ type parserTest struct {
 	tokens   []Token
 }
 
-func runTests(t *testing.T, tests []parserTest) {
+func runTests(t *testing.T, tests []parserTest, opts ...CompileOption) {
 	for _, test := range tests {
-		tmpl, err := Compile(test.template)
-		if assert.NoError(t, err, test.template) && assert.Len(t, tmpl.result.tokens, len(test.tokens)) {
+		tmpl, err := Compile(test.template, opts...)
+		if assert.NoError(t, err, test.template) && assert.Len(t, tmpl.tokens, len(test.tokens)) {
+			clearSource(tmpl.tokens)
 			for i := range test.tokens {
-				assert.Equal(t, test.tokens[i], tmpl.result.tokens[i], fmt.Sprintf("tokens at index %d are not equal", i))
+				assert.Equal(t, test.tokens[i], tmpl.tokens[i], fmt.Sprintf("tokens at index %d are not equal", i))
 			}
 		}
 	}
 }
 
+// clearSource zeroes the source positions recorded by the parser, which are
+// checked separately by TestPositions.
+func clearSource(tokens []Token) {
+	for _, token := range tokens {
+		switch token := token.(type) {
+		case *text:
+			token.source = source{}
+		case *variable:
+			token.source = source{}
+		case *partial:
+			token.source = source{}
+		case *section:
+			token.source = source{}
+			token.close = source{}
+			clearSource(token.tokens)
+			clearSource(token.elseTokens)
+		}
+	}
+}
+
 func TestText(t *testing.T) {
 	tokens := []Token{
 		&text{value: "This is an example string"},
@@ -176,3 +198,269 @@ func TestSection(t *testing.T) {
 	}
 	runTests(t, tests)
 }
+
+func TestFilters(t *testing.T) {
+	tests := []parserTest{
+		{
+			template: "{{ name | upper }}",
+			tokens: []Token{
+				&variable{name: "name", escape: true, filters: []FilterCall{{Name: "upper"}}},
+			},
+		},
+		{
+			template: "{{{name|trim|truncate 20}}}!",
+			tokens: []Token{
+				&variable{name: "name", escape: false, filters: []FilterCall{{Name: "trim"}, {Name: "truncate", Args: []string{"20"}}}},
+				&text{value: "!"},
+			},
+		},
+		{
+			template: `{{ title | default "No | title" | lower }}`,
+			tokens: []Token{
+				&variable{name: "title", escape: true, filters: []FilterCall{{Name: "default", Args: []string{"No | title"}}, {Name: "lower"}}},
+			},
+		},
+	}
+	runTests(t, tests, WithFilters(nil))
+
+	tmpl, err := Compile("{{ name | shout }}", WithFilters(FuncMap{"shout": upperFilter}))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "name", tmpl.Tokens()[0].Name())
+		assert.Equal(t, []FilterCall{{Name: "shout"}}, tmpl.Tokens()[0].Filters())
+	}
+
+	_, err = Compile("{{ name | shout }}", WithFilters(nil))
+	assert.Error(t, err)
+	_, err = Compile("{{ name | upper }}")
+	assert.Error(t, err)
+	_, err = Compile("{{ name | }}", WithFilters(nil))
+	assert.Error(t, err)
+
+	// The argument counts of the builtin filters are checked, unless they
+	// are overridden.
+	_, err = Compile("{{ x | truncate }}", WithFilters(nil))
+	assert.EqualError(t, err, `Filter "truncate" expects 1 argument(s), got 0`)
+	_, err = Compile("{{ x | upper extra }}", WithFilters(nil))
+	assert.EqualError(t, err, `Filter "upper" expects 0 argument(s), got 1`)
+	_, err = Compile("{{ x | date a b }}", WithFilters(nil))
+	assert.EqualError(t, err, `Filter "date" expects at most 1 argument(s), got 2`)
+	_, err = Compile("{{ x | upper extra }}", WithFilters(FuncMap{"upper": upperFilter}))
+	assert.NoError(t, err)
+}
+
+func TestParentAccess(t *testing.T) {
+	tests := []parserTest{
+		{
+			template: "{{#a}}{{../b}}{{/a}}",
+			tokens: []Token{
+				&section{
+					name: "a",
+					tokens: []Token{
+						&variable{name: "b", parents: 1, escape: true},
+					},
+				},
+			},
+		},
+		{
+			template: "{{#a}}{{#b}}{{#../../c}}{{{../d.e}}}{{/../../c}}{{/b}}{{/a}}",
+			tokens: []Token{
+				&section{
+					name: "a",
+					tokens: []Token{
+						&section{
+							name: "b",
+							tokens: []Token{
+								&section{
+									name:    "c",
+									parents: 2,
+									tokens: []Token{
+										&variable{name: "d.e", parents: 1, escape: false},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	runTests(t, tests, WithParentAccess())
+
+	// Without the option the name is left untouched.
+	tmpl, err := Compile("{{#a}}{{../b}}{{/a}}")
+	if assert.NoError(t, err) {
+		v := tmpl.Tokens()[0].Tokens()[0]
+		assert.Equal(t, "../b", v.Name())
+		assert.Equal(t, 0, v.Parents())
+	}
+
+	errorTests := []string{
+		"{{../a}}",
+		"{{^a}}{{../b}}{{/a}}",
+		"{{#a}}{{../../b}}{{/a}}",
+		"{{#a}}{{b/../c}}{{/a}}",
+		"{{#a}}{{../}}{{/a}}",
+		"{{#a}}{{#../b}}{{/b}}{{/a}}",
+		// The falsy branch of a section pushes no context.
+		"{{#a}}{{^}}{{../x}}{{/a}}",
+		"{{#a}}x{{else}}{{#b}}{{../../y}}{{/b}}{{/a}}",
+	}
+	for _, template := range errorTests {
+		_, err := Compile(template, WithParentAccess(), WithElseDivider())
+		assert.Error(t, err, template)
+	}
+
+	tmpl, err = Compile("{{#a}}{{../x}}{{else}}{{#b}}{{../y}}{{/b}}{{/a}}", WithParentAccess(), WithElseDivider())
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, tmpl.Tokens()[0].Tokens()[0].Parents())
+		assert.Equal(t, 1, tmpl.Tokens()[0].ElseTokens()[0].Tokens()[0].Parents())
+	}
+}
+
+func TestElseDivider(t *testing.T) {
+	tokens := []Token{
+		&section{
+			name: "items",
+			tokens: []Token{
+				&variable{name: "name", escape: true},
+			},
+			elseTokens: []Token{
+				&text{value: "None"},
+			},
+		},
+	}
+	tests := []parserTest{
+		{
+			template: "{{#items}}{{name}}{{^}}None{{/items}}",
+			tokens:   tokens,
+		},
+		{
+			template: "{{#items}}{{name}}{{ else }}None{{/items}}",
+			tokens:   tokens,
+		},
+		{
+			template: "{{#a}}{{#b}}{{^}}B{{/b}}{{else}}A{{/a}}",
+			tokens: []Token{
+				&section{
+					name: "a",
+					tokens: []Token{
+						&section{
+							name:       "b",
+							tokens:     []Token{},
+							elseTokens: []Token{&text{value: "B"}},
+						},
+					},
+					elseTokens: []Token{
+						&text{value: "A"},
+					},
+				},
+			},
+		},
+	}
+	runTests(t, tests, WithElseDivider())
+
+	tmpl, err := Compile("{{#a}}A{{/a}}", WithElseDivider())
+	if assert.NoError(t, err) {
+		assert.Nil(t, tmpl.Tokens()[0].ElseTokens())
+	}
+
+	// Without the option {{else}} is an ordinary variable.
+	tmpl, err = Compile("{{#a}}{{else}}{{/a}}")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "else", tmpl.Tokens()[0].Tokens()[0].Name())
+	}
+
+	errorTests := []string{
+		"{{else}}",
+		"{{^}}",
+		"{{^a}}{{else}}{{/a}}",
+		"{{#a}}{{^}}{{else}}{{/a}}",
+		"{{#a}}{{else | upper}}{{/a}}",
+	}
+	for _, template := range errorTests {
+		_, err := Compile(template, WithElseDivider(), WithFilters(nil))
+		assert.Error(t, err, template)
+	}
+}
+
+// TestConcurrentUse is most useful when run with the race detector. Render is
+// still a stub in this tree, so only concurrent reads of the token tree are
+// exercised; rendering should be added here once it is implemented.
+func TestConcurrentUse(t *testing.T) {
+	tmpl, err := Compile("{{#items}}{{name | upper}}{{^}}None{{/items}}{{>footer}}", WithFilters(nil), WithElseDivider())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var walk func(tokens []Token) int
+	walk = func(tokens []Token) int {
+		n := len(tokens)
+		for _, token := range tokens {
+			if token.Type() == Section || token.Type() == InvertedSection {
+				n += walk(token.Tokens()) + walk(token.ElseTokens())
+			}
+		}
+		return n
+	}
+
+	const goroutines = 200
+	var wg sync.WaitGroup
+	counts := make([]int, goroutines)
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			counts[i] = walk(tmpl.Tokens())
+		}(i)
+	}
+	wg.Wait()
+	for i := range counts {
+		assert.Equal(t, 4, counts[i])
+	}
+
+	// Modifying the returned slices does not modify the template.
+	tokens := tmpl.Tokens()
+	tokens[0].Tokens()[0] = &text{value: "x"}
+	tokens[0] = &text{value: "x"}
+	assert.Equal(t, Section, tmpl.Tokens()[0].Type())
+	assert.Equal(t, Variable, tmpl.Tokens()[0].Tokens()[0].Type())
+}
+
+func TestPositions(t *testing.T) {
+	tmpl, err := Compile("Hi {{name}}!\n  {{#a}}\n{{{b}}}{{/a}}\n{{>c}}")
+	if !assert.NoError(t, err) {
+		return
+	}
+	tokens := tmpl.Tokens()
+	if !assert.Len(t, tokens, 6) {
+		return
+	}
+	assert.Equal(t, &text{
+		source: source{start: Position{0, 1, 1}, end: Position{3, 1, 4}},
+		value:  "Hi ",
+	}, tokens[0])
+	assert.Equal(t, source{
+		start: Position{3, 1, 4},
+		end:   Position{11, 1, 12},
+		raw:   "{{name}}",
+	}, tokens[1].(*variable).source)
+	assert.Equal(t, Position{11, 1, 12}, tokens[2].Pos())
+
+	s := tokens[3].(*section)
+	assert.Equal(t, source{
+		start:      Position{15, 2, 3},
+		end:        Position{21, 2, 9},
+		raw:        "{{#a}}",
+		standalone: true,
+	}, s.source)
+	assert.Equal(t, source{
+		start: Position{29, 3, 8},
+		end:   Position{35, 3, 14},
+		raw:   "{{/a}}",
+	}, s.close)
+	assert.Equal(t, Position{22, 3, 1}, s.tokens[0].Pos())
+	assert.Equal(t, "{{{b}}}", s.tokens[0].(*variable).raw)
+
+	assert.Equal(t, Position{35, 3, 14}, tokens[4].Pos())
+	assert.Equal(t, Position{36, 4, 1}, tokens[5].Pos())
+}
@@ -0,0 +1,97 @@
+package mustache
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CompileSet compiles a set of named templates which may include each other
+// as partials. In addition to the errors reported by Compile, it reports
+// partial cycles which would recurse forever: a template which includes itself,
+// directly or through other partials, without any of the includes being
+// inside a section. Recursion guarded by a section (e.g. rendering a tree) is
+// allowed. Partials which are not part of the set are ignored.
+func CompileSet(contents map[string]string, opts ...CompileOption) (map[string]*Template, error) {
+	names := make([]string, 0, len(contents))
+	for name := range contents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	templates := make(map[string]*Template, len(contents))
+	for _, name := range names {
+		t, err := Compile(contents[name], opts...)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		templates[name] = t
+	}
+	if err := checkPartialCycles(templates); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// checkPartialCycles walks the graph of unconditional partial includes and
+// returns an error describing the first cycle found.
+func checkPartialCycles(templates map[string]*Template) error {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(templates))
+	var path []string
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visiting:
+			for i := range path {
+				if path[i] == name {
+					return fmt.Errorf("Partial cycle %s -> %s", strings.Join(path[i:], " -> "), name)
+				}
+			}
+		case visited:
+			return nil
+		}
+		state[name] = visiting
+		path = append(path, name)
+		for _, p := range unconditionalPartials(templates[name].Tokens()) {
+			if _, ok := templates[p]; !ok {
+				continue
+			}
+			if err := visit(p); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		return nil
+	}
+
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unconditionalPartials returns the names of the partials included by tokens
+// outside of any section, in order of appearance.
+func unconditionalPartials(tokens []Token) []string {
+	var names []string
+	for _, token := range tokens {
+		if token.Type() == Partial {
+			names = append(names, token.Name())
+		}
+	}
+	return names
+}
@@ -0,0 +1,86 @@
+package mustache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPartial(t *testing.T) {
+	tests := []parserTest{
+		{
+			template: "Hello, {{> user }}!",
+			tokens: []Token{
+				&text{value: "Hello, "},
+				&partial{name: "user"},
+				&text{value: "!"},
+			},
+		},
+		{
+			template: "{{#items}}{{>item}}{{/items}}",
+			tokens: []Token{
+				&section{
+					name:   "items",
+					tokens: []Token{&partial{name: "item"}},
+				},
+			},
+		},
+	}
+	runTests(t, tests)
+}
+
+func TestCompileSet(t *testing.T) {
+	templates, err := CompileSet(map[string]string{
+		"page":   "{{>header}}{{#items}}{{>item}}{{/items}}{{>missing}}",
+		"header": "<h1>{{title}}</h1>",
+		"item":   "{{name}}{{#children}}{{>item}}{{/children}}{{^children}}{{>leaf}}{{/children}}",
+		"leaf":   "{{>header}}",
+	})
+	if assert.NoError(t, err) {
+		assert.Len(t, templates, 4)
+		assert.Equal(t, Partial, templates["page"].Tokens()[0].Type())
+		assert.Equal(t, "header", templates["page"].Tokens()[0].Name())
+	}
+}
+
+func TestCompileSetErrors(t *testing.T) {
+	tests := []struct {
+		contents map[string]string
+		err      string
+	}{
+		{
+			contents: map[string]string{"a": "{{>a}}"},
+			err:      "Partial cycle a -> a",
+		},
+		{
+			contents: map[string]string{
+				"a": "{{#x}}{{>c}}{{/x}}{{>b}}",
+				"b": "text {{>c}}",
+				"c": "{{>a}}",
+			},
+			err: "Partial cycle a -> b -> c -> a",
+		},
+		{
+			contents: map[string]string{
+				"a": "{{>b}}",
+				"b": "{{>c}}",
+				"c": "{{>b}}",
+			},
+			err: "Partial cycle b -> c -> b",
+		},
+		{
+			contents: map[string]string{"a": "{{/x}}"},
+			err:      "a: Closing unopened section x",
+		},
+		{
+			contents: map[string]string{"a": "{{> }}"},
+			err:      "a: Missing partial name",
+		},
+	}
+	for _, test := range tests {
+		_, err := CompileSet(test.contents)
+		if assert.Error(t, err) {
+			assert.Equal(t, test.err, err.Error())
+		}
+	}
+}
@@ -3,6 +3,7 @@ package mustache
 import (
 	"fmt"
 	"regexp"
+	"sort"
 )
 
 // stringScanner is modeled off of Ruby's StringScanner, which is the
@@ -10,6 +11,7 @@ import (
 type stringScanner struct {
 	input string
 	pos   int
+	lines []int // offsets of the start of each line, computed by Position
 }
 
 // Scan tries to match the pattern at the current position. If there’s a match,
@@ -132,3 +134,18 @@ func (s *stringScanner) StartOfLine() bool {
 	}
 	return false
 }
+
+// Position returns the line and column of the given offset in the input
+// string.
+func (s *stringScanner) Position(offset int) Position {
+	if s.lines == nil {
+		s.lines = []int{0}
+		for i := 0; i < len(s.input); i++ {
+			if s.input[i] == '\n' {
+				s.lines = append(s.lines, i+1)
+			}
+		}
+	}
+	line := sort.Search(len(s.lines), func(i int) bool { return s.lines[i] > offset })
+	return Position{Offset: offset, Line: line, Column: offset - s.lines[line-1] + 1}
+}
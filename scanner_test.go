@@ -126,3 +126,13 @@ func TestStartOfLine(t *testing.T) {
 	scanner.Scan(regexp.MustCompile(`te`))
 	assert.False(t, scanner.StartOfLine())
 }
+
+func TestPosition(t *testing.T) {
+	scanner := &stringScanner{input: "ab\ncd\n\ne"}
+	assert.Equal(t, Position{Offset: 0, Line: 1, Column: 1}, scanner.Position(0))
+	assert.Equal(t, Position{Offset: 2, Line: 1, Column: 3}, scanner.Position(2))
+	assert.Equal(t, Position{Offset: 3, Line: 2, Column: 1}, scanner.Position(3))
+	assert.Equal(t, Position{Offset: 6, Line: 3, Column: 1}, scanner.Position(6))
+	assert.Equal(t, Position{Offset: 7, Line: 4, Column: 1}, scanner.Position(7))
+	assert.Equal(t, Position{Offset: 8, Line: 4, Column: 2}, scanner.Position(8))
+}
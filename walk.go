@@ -0,0 +1,101 @@
+package mustache
+
+// A Visitor's Visit method is invoked for each token encountered by Walk. If
+// the result visitor w is not nil, Walk visits each of the children of the
+// token with w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(token Token) (w Visitor)
+}
+
+// Walk traverses a token tree in depth-first order. For each token, it calls
+// v.Visit(token); if that returns a non-nil visitor w, Walk visits the
+// children of a section with w, those before any else divider first, then
+// calls w.Visit(nil).
+func Walk(tokens []Token, v Visitor) {
+	for _, token := range tokens {
+		w := v.Visit(token)
+		if w == nil {
+			continue
+		}
+		if children, elseChildren, ok := TokenChildren(token); ok {
+			Walk(children, w)
+			Walk(elseChildren, w)
+		}
+		w.Visit(nil)
+	}
+}
+
+type inspector struct {
+	f    func(Token, []Token) bool
+	path []Token
+}
+
+func (in *inspector) Visit(token Token) Visitor {
+	if token == nil {
+		return nil
+	}
+	if !in.f(token, in.path) {
+		return nil
+	}
+	return &inspector{f: in.f, path: append(in.path[:len(in.path):len(in.path)], token)}
+}
+
+// Inspect traverses the tokens of a template in depth-first order, calling
+// f(token, path) for each token, where path holds the sections enclosing the
+// token, outermost first. If f returns true, Inspect continues with the
+// children of the token. The path is only valid during the call to f.
+func Inspect(t *Template, f func(token Token, path []Token) bool) {
+	Walk(t.Tokens(), &inspector{f: f})
+}
+
+// TextValue returns the text of a text token. It reports false for other
+// tokens.
+func TextValue(token Token) (value string, ok bool) {
+	if t, ok := token.(*text); ok {
+		return t.value, true
+	}
+	return "", false
+}
+
+// TokenName returns the name of a section, variable or partial token. It is
+// the non-panicking form of Token.Name, and reports false for text tokens.
+func TokenName(token Token) (name string, ok bool) {
+	switch token.Type() {
+	case Section, InvertedSection, Variable, UnescapedVariable, Partial:
+		return token.Name(), true
+	}
+	return "", false
+}
+
+// TokenParents returns the number of `../` segments before the name of a
+// section, variable or partial token. It is the non-panicking form of
+// Token.Parents, and reports false for text tokens.
+func TokenParents(token Token) (parents int, ok bool) {
+	switch token.Type() {
+	case Section, InvertedSection, Variable, UnescapedVariable, Partial:
+		return token.Parents(), true
+	}
+	return 0, false
+}
+
+// TokenChildren returns the child tokens of a section, and those following
+// its else divider. It is the non-panicking form of Token.Tokens and
+// Token.ElseTokens, and reports false for tokens other than sections.
+func TokenChildren(token Token) (tokens, elseTokens []Token, ok bool) {
+	switch token.Type() {
+	case Section, InvertedSection:
+		return token.Tokens(), token.ElseTokens(), true
+	}
+	return nil, nil, false
+}
+
+// TokenFilters returns the filter chain of a variable token. It is the
+// non-panicking form of Token.Filters, and reports false for tokens other than
+// variables.
+func TokenFilters(token Token) (filters []FilterCall, ok bool) {
+	switch token.Type() {
+	case Variable, UnescapedVariable:
+		return token.Filters(), true
+	}
+	return nil, false
+}
@@ -0,0 +1,107 @@
+package mustache
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recorder struct {
+	events *[]string
+	depth  int
+}
+
+func (r recorder) Visit(token Token) Visitor {
+	if token == nil {
+		*r.events = append(*r.events, fmt.Sprintf("%d:end", r.depth))
+		return nil
+	}
+	desc := token.Type().String()
+	if name, ok := TokenName(token); ok {
+		desc += " " + name
+	}
+	*r.events = append(*r.events, fmt.Sprintf("%d:%s", r.depth, desc))
+	if token.Type() == Partial {
+		// Don't descend, so no end event is recorded.
+		return nil
+	}
+	return recorder{events: r.events, depth: r.depth + 1}
+}
+
+func TestWalk(t *testing.T) {
+	tmpl, err := Compile("a{{#s}}{{v}}{{else}}{{>p}}{{/s}}{{^i}}b{{/i}}", WithElseDivider())
+	if !assert.NoError(t, err) {
+		return
+	}
+	var events []string
+	Walk(tmpl.Tokens(), recorder{events: &events})
+	assert.Equal(t, []string{
+		"0:Text", "1:end",
+		"0:Section s", "1:Variable v", "2:end", "1:Partial p", "1:end",
+		"0:InvertedSection i", "1:Text", "2:end", "1:end",
+	}, events)
+}
+
+func TestInspect(t *testing.T) {
+	tmpl, err := Compile("{{#a}}{{#b}}{{c}}{{/b}}{{d}}{{/a}}{{#e}}{{f}}{{/e}}")
+	if !assert.NoError(t, err) {
+		return
+	}
+	var seen []string
+	Inspect(tmpl, func(token Token, path []Token) bool {
+		var names []string
+		for _, p := range path {
+			names = append(names, p.Name())
+		}
+		names = append(names, token.Name())
+		seen = append(seen, strings.Join(names, "."))
+		return token.Name() != "e"
+	})
+	assert.Equal(t, []string{"a", "a.b", "a.b.c", "a.d", "e"}, seen)
+}
+
+func TestAccessors(t *testing.T) {
+	tmpl, err := Compile("hi {{#s}}{{../name | upper}}{{else}}y{{/s}}{{>p}}", WithFilters(nil), WithParentAccess(), WithElseDivider())
+	if !assert.NoError(t, err) {
+		return
+	}
+	tokens := tmpl.Tokens()
+	if !assert.Len(t, tokens, 3) {
+		return
+	}
+	text, section, partial := tokens[0], tokens[1], tokens[2]
+	variable := section.Tokens()[0]
+
+	value, ok := TextValue(text)
+	assert.True(t, ok)
+	assert.Equal(t, "hi ", value)
+	_, ok = TextValue(variable)
+	assert.False(t, ok)
+
+	_, ok = TokenName(text)
+	assert.False(t, ok)
+	name, ok := TokenName(partial)
+	assert.True(t, ok)
+	assert.Equal(t, "p", name)
+
+	_, ok = TokenParents(text)
+	assert.False(t, ok)
+	parents, ok := TokenParents(variable)
+	assert.True(t, ok)
+	assert.Equal(t, 1, parents)
+
+	_, _, ok = TokenChildren(variable)
+	assert.False(t, ok)
+	children, elseChildren, ok := TokenChildren(section)
+	assert.True(t, ok)
+	assert.Len(t, children, 1)
+	assert.Len(t, elseChildren, 1)
+
+	_, ok = TokenFilters(section)
+	assert.False(t, ok)
+	filters, ok := TokenFilters(variable)
+	assert.True(t, ok)
+	assert.Equal(t, []FilterCall{{Name: "upper"}}, filters)
+}